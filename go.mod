@@ -4,6 +4,6 @@ go 1.15
 
 require (
 	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646
-	github.com/signintech/gopdf v0.10.6
+	github.com/signintech/gopdf v0.14.0
 	github.com/spf13/pflag v1.0.3
 )
@@ -2,11 +2,12 @@ package main
 
 import (
 	"apple-x-co/go-pdf/pdf"
-	"apple-x-co/go-pdf/types"
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"log"
 	"os"
 
 	flag "github.com/spf13/pflag"
@@ -17,9 +18,10 @@ var revision string
 
 func main() {
 	var (
-		inputPath   = flag.StringP("in", "i", "layout.json", "file path of input json.")
-		outputPath  = flag.StringP("out", "o", "output.configure", "file path of output configure.")
+		inputPath   = flag.StringP("in", "i", "layout.json", "file path of input json. use - for stdin.")
+		outputPath  = flag.StringP("out", "o", "output.configure", "file path of output configure. use - for stdout.")
 		ttfPath     = flag.StringP("ttf", "t", "fonts/TakaoPGothic.ttf", "file path of ttf.")
+		dataPath    = flag.StringP("data", "d", "", "file path of data json for text templates.")
 		showHelp    = flag.BoolP("help", "h", false, "show help message")
 		showVersion = flag.BoolP("version", "v", false, "show version")
 	)
@@ -34,48 +36,43 @@ func main() {
 		os.Exit(0)
 	}
 
-	execute(*inputPath, *outputPath, *ttfPath)
-
-	os.Exit(0)
-}
-
-func execute(inputPath string, outputPath string, ttfPath string) {
-	f, err := os.Open(inputPath)
-	if err != nil {
-		fmt.Println("error:", err)
+	if err := run(*inputPath, *outputPath, *ttfPath, *dataPath); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
 		os.Exit(1)
 	}
-	defer f.Close()
+}
 
-	b, err := ioutil.ReadAll(f)
-	if err != nil {
-		fmt.Println("error:", err)
-		os.Exit(1)
+// 入力の JSON を読み込み、PDF を書き出す（終了コードは main で決める）
+func run(inputPath string, outputPath string, ttfPath string, dataPath string) error {
+	var in io.Reader = os.Stdin
+	if inputPath != "-" {
+		f, err := os.Open(inputPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
 	}
 
-	var documentConfigure = types.DocumentConfigure{
-		Margin:        types.Margin{Top: 10, Right: 10, Bottom: 10, Left: 10},
-		TextSize:      pdf.DefaultTextSize,
-		TextColor:     types.Color{R: pdf.DefaultColorR, G: pdf.DefaultColorG, B: pdf.DefaultColorB},
-		AutoPageBreak: true,
-		CompressLevel: pdf.DefaultCompressLevel,
-		TTFPath:       ttfPath,
-		CommonHeader:  types.Header{Size: types.Size{Width: pdf.UnsetWidth, Height: pdf.UnsetHeight}},
-		CommonFooter:  types.Footer{Size: types.Size{Width: pdf.UnsetWidth, Height: pdf.UnsetHeight}},
+	var data interface{}
+	if dataPath != "" {
+		b, err := ioutil.ReadFile(dataPath)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(b, &data); err != nil {
+			return err
+		}
 	}
-	bytes := []byte(string(b))
-	if err := json.Unmarshal(bytes, &documentConfigure); err != nil {
-		fmt.Println("error:", err)
-		os.Exit(1)
+
+	var buf bytes.Buffer
+	if err := pdf.Render(context.Background(), in, &buf, pdf.Options{TTFPath: ttfPath, Data: data}); err != nil {
+		return err
 	}
-	//fmt.Printf("%v\n", configure)
 
-	document := pdf.PDF{}
-	document.Draw(documentConfigure)
-	if err := document.Save(outputPath); err != nil {
-		document.Destroy()
-		log.Print(err.Error())
-		os.Exit(1)
+	if outputPath == "-" {
+		_, err := buf.WriteTo(os.Stdout)
+		return err
 	}
-	document.Destroy()
+	return ioutil.WriteFile(outputPath, buf.Bytes(), 0644)
 }
@@ -0,0 +1,86 @@
+package pdf
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// データ参照：「Data.items」「Item.lines」のようなドット区切りの式を解決する
+func (p *PDF) lookup(expression string) (interface{}, error) {
+	keys := strings.Split(strings.TrimPrefix(strings.TrimSpace(expression), "."), ".")
+
+	var value interface{}
+	switch keys[0] {
+	case "Data":
+		value = p.data
+	case "Item":
+		value = p.item
+	default:
+		return nil, fmt.Errorf("unknown root %q (use Data or Item)", keys[0])
+	}
+
+	for _, key := range keys[1:] {
+		v := reflect.ValueOf(value)
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			v = v.Elem()
+		}
+		if !v.IsValid() {
+			return nil, nil
+		}
+
+		switch v.Kind() {
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return nil, fmt.Errorf("cannot resolve %q: map key is not string", key)
+			}
+			mapValue := v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key()))
+			if !mapValue.IsValid() {
+				return nil, nil
+			}
+			value = mapValue.Interface()
+		case reflect.Struct:
+			field := v.FieldByName(key)
+			if !field.IsValid() || !field.CanInterface() {
+				return nil, fmt.Errorf("cannot resolve %q: no such field", key)
+			}
+			value = field.Interface()
+		case reflect.Slice, reflect.Array:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= v.Len() {
+				return nil, fmt.Errorf("cannot resolve %q: invalid index", key)
+			}
+			value = v.Index(index).Interface()
+		default:
+			return nil, fmt.Errorf("cannot resolve %q in %s", key, v.Kind())
+		}
+	}
+
+	return value, nil
+}
+
+// データ参照：繰り返し対象の配列
+func (p *PDF) lookupItems(expression string) ([]interface{}, error) {
+	value, err := p.lookup(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("%q is not an array but %s", expression, v.Kind())
+	}
+
+	items := make([]interface{}, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return items, nil
+}
@@ -0,0 +1,127 @@
+package pdf
+
+import (
+	"apple-x-co/go-pdf/types"
+	"errors"
+	"fmt"
+)
+
+// エラー：フォント読み込み
+type FontLoadError struct {
+	PageIndex int
+	Path      string
+	FontPath  string
+	Err       error
+}
+
+func (e *FontLoadError) Error() string {
+	if e.FontPath == "" {
+		return fmt.Sprintf("%s: font load: %v", location(e.PageIndex, e.Path), e.Err)
+	}
+	return fmt.Sprintf("%s: font load %q: %v", location(e.PageIndex, e.Path), e.FontPath, e.Err)
+}
+
+func (e *FontLoadError) Unwrap() error {
+	return e.Err
+}
+
+// エラー：画像読み込み
+type ImageDecodeError struct {
+	PageIndex int
+	Path      string
+	ImagePath string
+	Err       error
+}
+
+func (e *ImageDecodeError) Error() string {
+	return fmt.Sprintf("%s: image decode %q: %v", location(e.PageIndex, e.Path), e.ImagePath, e.Err)
+}
+
+func (e *ImageDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// エラー：要素の属性の読み込み（不正な値・パレットにない色の名前）
+type AttributeDecodeError struct {
+	PageIndex int
+	Path      string
+	Err       error
+}
+
+func (e *AttributeDecodeError) Error() string {
+	return fmt.Sprintf("%s: attribute decode: %v", location(e.PageIndex, e.Path), e.Err)
+}
+
+func (e *AttributeDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// エラー：テンプレート
+type TemplateParseError struct {
+	PageIndex int
+	Path      string
+	Text      string
+	Err       error
+}
+
+func (e *TemplateParseError) Error() string {
+	return fmt.Sprintf("%s: template parse %q: %v", location(e.PageIndex, e.Path), e.Text, e.Err)
+}
+
+func (e *TemplateParseError) Unwrap() error {
+	return e.Err
+}
+
+// エラー：レイアウトがページに収まらない
+type LayoutOverflowError struct {
+	PageIndex int
+	Path      string
+	Size      types.Size
+	Available types.Size
+}
+
+func (e *LayoutOverflowError) Error() string {
+	return fmt.Sprintf("%s: layout overflow: %.2fx%.2f does not fit in %.2fx%.2f", location(e.PageIndex, e.Path), e.Size.Width, e.Size.Height, e.Available.Width, e.Available.Height)
+}
+
+// エラー：描き直しても総ページ数が確定しない
+type PageCountError struct {
+	Passes int
+}
+
+func (e *PageCountError) Error() string {
+	return fmt.Sprintf("page count did not settle after %d layout passes", e.Passes)
+}
+
+// エラー：描画中のフォントエラーに要素のパスを付与（フォント以外のエラーはそのまま返す）
+func (p *PDF) fontError(err error, path string) error {
+	var fontLoadError *FontLoadError
+	if errors.As(err, &fontLoadError) && fontLoadError.Path == "" {
+		fontLoadError.Path = path
+	}
+	return err
+}
+
+// エラー：画像の読み込みに失敗
+func (p *PDF) imageDecodeError(err error, imagePath string) error {
+	return &ImageDecodeError{PageIndex: p.pageIndex(), ImagePath: imagePath, Err: err}
+}
+
+// エラー：描画中の画像エラーに要素のパスを付与（画像以外のエラーはそのまま返す）
+func (p *PDF) imageError(err error, path string) error {
+	var imageDecodeError *ImageDecodeError
+	if errors.As(err, &imageDecodeError) && imageDecodeError.Path == "" {
+		imageDecodeError.Path = path
+	}
+	return err
+}
+
+func location(pageIndex int, path string) string {
+	if path == "" {
+		path = "$"
+	}
+	if pageIndex < 0 {
+		return path
+	}
+	return fmt.Sprintf("%s (page index %d)", path, pageIndex)
+}
@@ -0,0 +1,76 @@
+package pdf
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRenderErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		layout    string
+		target    interface{}
+		pageIndex int
+		path      string
+	}{
+		{
+			name:      "font file",
+			layout:    `{"width": 400, "height": 400, "fonts": {"default": {"bold": "testdata/missing.ttf"}}, "pages": [{"liner_layout": {"elements": []}}]}`,
+			target:    new(*FontLoadError),
+			pageIndex: -1,
+			path:      "fonts.default.bold",
+		},
+		{
+			name:      "font family",
+			layout:    `{"width": 400, "height": 400, "pages": [{"liner_layout": {"elements": [{"type": "text", "attributes": {"text": "a", "font_family": "nothing"}}]}}]}`,
+			target:    new(*FontLoadError),
+			pageIndex: 0,
+			path:      "pages[0].liner_layout.elements[0]",
+		},
+		{
+			name:      "image",
+			layout:    `{"width": 400, "height": 400, "pages": [{"liner_layout": {"elements": []}}, {"liner_layout": {"elements": [{"type": "image", "attributes": {"path": "testdata/missing.png"}}]}}]}`,
+			target:    new(*ImageDecodeError),
+			pageIndex: 1,
+			path:      "pages[1].liner_layout.elements[0]",
+		},
+		{
+			name:      "template",
+			layout:    `{"width": 400, "height": 400, "pages": [{"liner_layout": {"liner_layouts": [{"elements": [{"type": "text", "attributes": {"text": "{{.Data"}}]}]}}]}`,
+			target:    new(*TemplateParseError),
+			pageIndex: 0,
+			path:      "pages[0].liner_layout.liner_layouts[0].elements[0]",
+		},
+		{
+			// ページの先頭から収まらない場合は白紙のページを追加せずにエラーにする
+			name:      "overflow",
+			layout:    `{"width": 400, "height": 400, "pages": [{"liner_layout": {"elements": [{"type": "text", "attributes": {"text": "a", "size": {"width": 100, "height": 1000}}}]}}]}`,
+			target:    new(*LayoutOverflowError),
+			pageIndex: 0,
+			path:      "pages[0].liner_layout.elements[0]",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := renderTestLayout(t, test.layout, nil)
+			if !errors.As(err, test.target) {
+				t.Fatalf("Render() error = %v, want %T", err, test.target)
+			}
+			var pageIndex int
+			var path string
+			switch target := test.target.(type) {
+			case **FontLoadError:
+				pageIndex, path = (*target).PageIndex, (*target).Path
+			case **ImageDecodeError:
+				pageIndex, path = (*target).PageIndex, (*target).Path
+			case **TemplateParseError:
+				pageIndex, path = (*target).PageIndex, (*target).Path
+			case **LayoutOverflowError:
+				pageIndex, path = (*target).PageIndex, (*target).Path
+			}
+			if pageIndex != test.pageIndex || path != test.path {
+				t.Errorf("Render() error at (%d, %q), want (%d, %q)", pageIndex, path, test.pageIndex, test.path)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package pdf
+
+import (
+	"apple-x-co/go-pdf/types"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 省略記号
+const ellipsis = "…"
+
+// 枠に収める：shrink は文字サイズを min_text_size まで小さくし、ellipsis は末尾を省略する
+func (p *PDF) fitText(documentConfigure types.DocumentConfigure, decoded types.ElementText, textRect types.Rect) (types.ElementText, error) {
+	// 大きさを指定していない場合は枠が文字に合わせて決まる
+	if decoded.Size.IsZero() && !decoded.Layout.Width.IsMatchParent() && !decoded.Layout.Height.IsMatchParent() {
+		return decoded, nil
+	}
+
+	if decoded.Fit.IsShrink() {
+		minTextSize := decoded.MinTextSize
+		if minTextSize == 0 {
+			minTextSize = DefaultMinTextSize
+		}
+		for size := decoded.TextSize; size > minTextSize; size-- {
+			ok, err := p.fitsText(documentConfigure, scaleText(decoded, size), textRect)
+			if err != nil || ok {
+				return scaleText(decoded, size), err
+			}
+		}
+		if minTextSize < decoded.TextSize {
+			return scaleText(decoded, minTextSize), nil
+		}
+	} else if decoded.Fit.IsEllipsis() {
+		if ok, err := p.fitsText(documentConfigure, decoded, textRect); err != nil || ok {
+			return decoded, err
+		}
+		// 収まる最長の文字数を探す（ルビの付いた文字列は1文字として数える）
+		length := textLength(decoded)
+		var fitErr error
+		n := sort.Search(length, func(n int) bool {
+			ok, err := p.fitsText(documentConfigure, truncateText(decoded, length-n), textRect)
+			if err != nil {
+				fitErr = err
+			}
+			return ok
+		})
+		return truncateText(decoded, length-n), fitErr
+	}
+	return decoded, nil
+}
+
+// 判定：テキストが枠に収まる
+func (p *PDF) fitsText(documentConfigure types.DocumentConfigure, decoded types.ElementText, textRect types.Rect) (bool, error) {
+	// 計算誤差で溢れたと判定しないための余裕
+	const tolerance = 0.01
+
+	var size types.Size
+	if decoded.WritingMode.IsVertical() {
+		decoded.Size = types.Size{}
+		if decoded.Wrap {
+			decoded.Size.Height = textRect.Height()
+		}
+		measureSize, err := p.measureVerticalText(decoded)
+		if err != nil {
+			return false, err
+		}
+		size = measureSize
+	} else if decoded.Wrap {
+		if _, err := p.setFont(decoded.FontFamily, decoded.FontStyle, decoded.TextSize); err != nil {
+			return false, err
+		}
+		lines, err := p.wrapText(p.textSpans(decoded), textRect.Width())
+		if err != nil {
+			return false, err
+		}
+		for i, line := range lines {
+			if i > 0 && lines[i-1].end {
+				size.Height += decoded.ParagraphSpacing
+			}
+			size.Height += decoded.LineHeight.Apply(line.height())
+			size.Width = math.Max(size.Width, line.width)
+		}
+	} else {
+		decoded.Size = types.Size{}
+		measureSize, err := p.measureText(documentConfigure, decoded)
+		if err != nil {
+			return false, err
+		}
+		size = measureSize
+	}
+	return size.Width <= textRect.Width()+tolerance && size.Height <= textRect.Height()+tolerance, nil
+}
+
+// 文字サイズの変更（spans の文字サイズも同じ割合で変える）
+func scaleText(decoded types.ElementText, size int) types.ElementText {
+	spans := make([]types.TextSpan, len(decoded.Spans))
+	for i, span := range decoded.Spans {
+		if span.TextSize != 0 {
+			span.TextSize = int(math.Max(1, math.Round(float64(span.TextSize*size)/float64(decoded.TextSize))))
+		}
+		spans[i] = span
+	}
+	decoded.Spans = spans
+	decoded.TextSize = size
+	return decoded
+}
+
+// テキストの文字数（spans の場合は合計、ルビの付いた文字列は1文字）
+func textLength(decoded types.ElementText) int {
+	if len(decoded.Spans) == 0 {
+		return len(textUnits(decoded.Text))
+	}
+	var length int
+	for _, span := range decoded.Spans {
+		length += len(textUnits(span.Text))
+	}
+	return length
+}
+
+// 分割：描画する単位（ルビの付いた文字列 {親文字|ルビ} は分割しない）
+func textUnits(text string) []string {
+	var units []string
+	var last = 0
+	for _, match := range rubyPattern.FindAllStringIndex(text, -1) {
+		for _, r := range text[last:match[0]] {
+			units = append(units, string(r))
+		}
+		units = append(units, text[match[0]:match[1]])
+		last = match[1]
+	}
+	for _, r := range text[last:] {
+		units = append(units, string(r))
+	}
+	return units
+}
+
+// 省略：先頭から length 文字（ルビの付いた文字列は1文字）を残して省略記号を付ける
+func truncateText(decoded types.ElementText, length int) types.ElementText {
+	cut := func(units []string) string {
+		return strings.TrimRightFunc(strings.Join(units[:length], ""), unicode.IsSpace) + ellipsis
+	}
+	if len(decoded.Spans) == 0 {
+		decoded.Text = cut(textUnits(decoded.Text))
+		return decoded
+	}
+
+	var spans []types.TextSpan
+	for _, span := range decoded.Spans {
+		units := textUnits(span.Text)
+		if n := len(units); n < length {
+			spans = append(spans, span)
+			length -= n
+			continue
+		}
+		span.Text = cut(units)
+		spans = append(spans, span)
+		break
+	}
+	decoded.Spans = spans
+	return decoded
+}
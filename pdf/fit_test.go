@@ -0,0 +1,95 @@
+package pdf
+
+import (
+	"apple-x-co/go-pdf/types"
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		name    string
+		decoded types.ElementText
+		length  int
+		want    types.ElementText
+	}{
+		{
+			name:    "text",
+			decoded: types.ElementText{Text: "Hello world"},
+			length:  4,
+			want:    types.ElementText{Text: "Hell…"},
+		},
+		{
+			name:    "trailing space",
+			decoded: types.ElementText{Text: "Hello world"},
+			length:  6,
+			want:    types.ElementText{Text: "Hello…"},
+		},
+		{
+			name:    "ruby is one unit",
+			decoded: types.ElementText{Text: "{漢字|かんじ}を読む"},
+			length:  2,
+			want:    types.ElementText{Text: "{漢字|かんじ}を…"},
+		},
+		{
+			name:    "ruby is not cut",
+			decoded: types.ElementText{Text: "読む{漢字|かんじ}"},
+			length:  2,
+			want:    types.ElementText{Text: "読む…"},
+		},
+		{
+			name:    "spans",
+			decoded: types.ElementText{Spans: []types.TextSpan{{Text: "{AB|ab}C"}, {Text: "DEF"}, {Text: "GHI"}}},
+			length:  4,
+			want:    types.ElementText{Spans: []types.TextSpan{{Text: "{AB|ab}C"}, {Text: "DE…"}}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := truncateText(test.decoded, test.length); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("truncateText(%d) = %+v, want %+v", test.length, got, test.want)
+			}
+		})
+	}
+}
+
+var tfPattern = regexp.MustCompile(`/F\d+ (\d+) Tf`)
+
+func TestFitText(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		fit    string
+		size   string
+		glyphs int
+	}{
+		// 収まる場合はそのまま
+		{name: "fits", text: "Hello", fit: "shrink", size: "20", glyphs: 5},
+		// 幅 60 に収まるまで小さくする
+		{name: "shrink", text: "Hello world", fit: "shrink", size: "12", glyphs: 11},
+		// 幅 60 に収まる "Hell" と省略記号
+		{name: "ellipsis", text: "Hello world wide", fit: "ellipsis", size: "20", glyphs: 5},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := renderTestLayout(t, fmt.Sprintf(`{
+  "width": 400, "height": 400, "compress_level": 0,
+  "pages": [{"liner_layout": {"elements": [
+    {"type": "text", "attributes": {"text": %q, "text_size": 20, "fit": %q, "size": {"width": 60, "height": 30}}}
+  ]}}]
+}`, test.text, test.fit), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tfPattern.FindSubmatch(b); got == nil || string(got[1]) != test.size {
+				t.Errorf("text size = %s, want %s", got, test.size)
+			}
+			tj := tjPattern.Find(b)
+			if got := (len(tj) - len("[<>] TJ")) / 4; got != test.glyphs {
+				t.Errorf("glyphs = %d (%s), want %d", got, tj, test.glyphs)
+			}
+		})
+	}
+}
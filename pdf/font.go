@@ -0,0 +1,193 @@
+package pdf
+
+import (
+	"apple-x-co/go-pdf/types"
+	"fmt"
+	"github.com/signintech/gopdf/fontmaker/core"
+	"io/ioutil"
+	"sort"
+)
+
+const DefaultFontFamily = "default"
+
+type font struct {
+	name               string
+	path               string
+	height             float64
+	ascender           float64
+	descender          float64
+	underlinePosition  float64
+	underlineThickness float64
+	strikeoutPosition  float64
+	data               []byte
+}
+
+// フォント読み込み：ファミリー・スタイルごとに別名で読み込む（描き直しても読み込みは 1 回）
+func (p *PDF) loadFonts(documentConfigure types.DocumentConfigure) error {
+	p.fonts = map[string]font{}
+	p.glyphs = map[string]map[rune]bool{}
+
+	families := map[string]types.FontFamily{}
+	for name, family := range documentConfigure.Fonts {
+		families[name] = family
+	}
+	defaultFamily := families[DefaultFontFamily]
+	if defaultFamily.Regular == "" {
+		defaultFamily.Regular = documentConfigure.TTFPath
+	}
+	families[DefaultFontFamily] = defaultFamily
+
+	// 出力を安定させるため名前順に登録する
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		family := families[name]
+		for _, style := range []types.FontStyle{types.FontStyleRegular, types.FontStyleBold, types.FontStyleItalic, types.FontStyleBoldItalic} {
+			path := family.Path(style)
+			if path == "" {
+				continue
+			}
+
+			var jsonPath = fmt.Sprintf("fonts.%s.%s", name, style)
+			if name == DefaultFontFamily && style.IsRegular() && path == documentConfigure.TTFPath {
+				jsonPath = ""
+			}
+
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return &FontLoadError{PageIndex: -1, Path: jsonPath, FontPath: path, Err: err}
+			}
+			var parser core.TTFParser
+			if err := parser.ParseFontData(data); err != nil {
+				return &FontLoadError{PageIndex: -1, Path: jsonPath, FontPath: path, Err: err}
+			}
+			p.fonts[fontName(name, style)] = font{
+				name:               fontName(name, style),
+				path:               path,
+				height:             float64(parser.Ascender()+parser.XHeight()+parser.Descender()) * 1000.00 / float64(parser.UnitsPerEm()),
+				ascender:           float64(parser.TypoAscender()) * 1000.00 / float64(parser.UnitsPerEm()),
+				descender:          float64(parser.TypoDescender()) * 1000.00 / float64(parser.UnitsPerEm()),
+				underlinePosition:  float64(parser.UnderlinePosition()) * 1000.00 / float64(parser.UnitsPerEm()),
+				underlineThickness: float64(parser.UnderlineThickness()) * 1000.00 / float64(parser.UnitsPerEm()),
+				strikeoutPosition:  float64(parser.XHeight()) * 0.5 * 1000.00 / float64(parser.UnitsPerEm()),
+				data:               data,
+			}
+		}
+	}
+
+	// FALLBACK
+	for i, name := range documentConfigure.FallbackFonts {
+		if _, ok := families[name]; !ok {
+			return &FontLoadError{PageIndex: -1, Path: fmt.Sprintf("fallback_fonts[%d]", i), Err: fmt.Errorf("font family %q is not defined in fonts", name)}
+		}
+	}
+	p.fallbackFonts = documentConfigure.FallbackFonts
+
+	return nil
+}
+
+// フォント登録：読み込んだフォントを PDF に登録する
+func (p *PDF) addFonts() error {
+	// 出力を安定させるため名前順に登録する
+	names := make([]string, 0, len(p.fonts))
+	for name := range p.fonts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := p.fonts[name]
+		if err := p.gp.AddTTFFontData(f.name, f.data); err != nil {
+			return &FontLoadError{PageIndex: -1, FontPath: f.path, Err: err}
+		}
+	}
+	return nil
+}
+
+// フォント選択：スタイルがない場合は近いスタイルを使う
+func (p *PDF) resolveFont(family string, style types.FontStyle) (font, error) {
+	if family == "" {
+		family = DefaultFontFamily
+	}
+	for _, fallback := range style.Fallbacks() {
+		if f, ok := p.fonts[fontName(family, fallback)]; ok {
+			return f, nil
+		}
+	}
+	return font{}, &FontLoadError{PageIndex: p.pageIndex(), Err: fmt.Errorf("font family %q is not defined in fonts", family)}
+}
+
+// フォント設定
+func (p *PDF) setFont(family string, style types.FontStyle, size interface{}) (font, error) {
+	f, err := p.resolveFont(family, style)
+	if err != nil {
+		return f, err
+	}
+	if err := p.gp.SetFont(f.name, "", size); err != nil {
+		return f, &FontLoadError{PageIndex: p.pageIndex(), FontPath: f.path, Err: err}
+	}
+	return f, nil
+}
+
+// フォント設定：解決済みのフォント
+func (p *PDF) useFont(f font, size float64) error {
+	if err := p.gp.SetFont(f.name, "", size); err != nil {
+		return &FontLoadError{PageIndex: p.pageIndex(), FontPath: f.path, Err: err}
+	}
+	return nil
+}
+
+// フォント選択：文字が主フォントにない場合は最初に文字を持つフォールバックフォントを使う
+func (p *PDF) resolveGlyphFont(primary font, style types.FontStyle, r rune) (font, error) {
+	if r == '\n' {
+		return primary, nil
+	}
+	if ok, err := p.hasGlyph(primary, r); err != nil || ok {
+		return primary, err
+	}
+	for _, family := range p.fallbackFonts {
+		f, err := p.resolveFont(family, style)
+		if err != nil {
+			return primary, err
+		}
+		if ok, err := p.hasGlyph(f, r); err != nil {
+			return primary, err
+		} else if ok {
+			return f, nil
+		}
+	}
+	return primary, nil
+}
+
+// 判定：フォントに文字がある
+func (p *PDF) hasGlyph(f font, r rune) (bool, error) {
+	glyphs, ok := p.glyphs[f.name]
+	if !ok {
+		glyphs = map[rune]bool{}
+		p.glyphs[f.name] = glyphs
+	}
+	if contains, ok := glyphs[r]; ok {
+		return contains, nil
+	}
+
+	if err := p.gp.SetFont(f.name, "", 1); err != nil {
+		return false, &FontLoadError{PageIndex: p.pageIndex(), FontPath: f.path, Err: err}
+	}
+	contains, err := p.gp.IsCurrFontContainGlyph(r)
+	if err != nil {
+		return false, &FontLoadError{PageIndex: p.pageIndex(), FontPath: f.path, Err: err}
+	}
+	glyphs[r] = contains
+	return contains, nil
+}
+
+func fontName(family string, style types.FontStyle) string {
+	if style.IsRegular() {
+		return family
+	}
+	return family + ":" + string(style)
+}
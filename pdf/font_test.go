@@ -0,0 +1,45 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+const testFallbackFontPath = "testdata/DejaVuSansMono.ttf"
+
+func TestFallbackFont(t *testing.T) {
+	tests := []struct {
+		name          string
+		fallbackFonts string
+		runs          [][2]string // フォントのパスと、そのフォントで描画する文字列
+		missing       [][2]string
+	}{
+		// 主フォントにない文字（✓）は最初にその文字を持つフォールバックフォントで描画する
+		{name: "fallback", fallbackFonts: `["mono"]`, runs: [][2]string{{testFontPath, "OK "}, {testFallbackFontPath, "✓"}}},
+		{name: "no fallback", fallbackFonts: `[]`, missing: [][2]string{{testFallbackFontPath, "✓"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := renderTestLayout(t, fmt.Sprintf(`{
+  "width": 400, "height": 400, "compress_level": 0,
+  "fonts": {"mono": {"regular": %q}},
+  "fallback_fonts": %s,
+  "pages": [{"liner_layout": {"elements": [{"type": "text", "attributes": {"text": "OK ✓"}}]}}]
+}`, testFallbackFontPath, test.fallbackFonts), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, run := range test.runs {
+				if !bytes.Contains(b, textTJ(t, run[0], run[1])) {
+					t.Errorf("output does not contain %q drawn with %s", run[1], run[0])
+				}
+			}
+			for _, run := range test.missing {
+				if bytes.Contains(b, textTJ(t, run[0], run[1])) {
+					t.Errorf("output contains %q drawn with %s", run[1], run[0])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package pdf
+
+import (
+	"unicode"
+)
+
+// 両端揃え：行の余りを語間に配分する（和文は字間にも配分する）
+func (p *PDF) justifyLine(line textLine, width float64) (textLine, error) {
+	if line.end || line.width >= width {
+		return line, nil
+	}
+
+	glyphs, err := p.lineGlyphs(line)
+	if err != nil {
+		return line, err
+	}
+
+	var count int
+	for i := 0; i+1 < len(glyphs); i++ {
+		if isJustifiable(glyphs[i].r, glyphs[i+1].r) {
+			count++
+		}
+	}
+	if count == 0 {
+		return line, nil
+	}
+
+	gap := (width - line.width) / float64(count)
+	for i := 0; i+1 < len(glyphs); i++ {
+		if isJustifiable(glyphs[i].r, glyphs[i+1].r) {
+			glyphs[i].gap += gap
+		}
+	}
+
+	justified := newTextLine(glyphs, line.font, line.size)
+	justified.end = line.end
+	return justified, nil
+}
+
+// 判定：文字の後ろに幅を配分できる
+func isJustifiable(r rune, next rune) bool {
+	if unicode.IsSpace(r) {
+		return true
+	}
+	if unicode.IsSpace(next) {
+		return false
+	}
+	return isCJK(r) || isCJK(next)
+}
@@ -0,0 +1,86 @@
+package pdf
+
+import (
+	"strings"
+	"unicode"
+)
+
+// 行頭禁則文字
+const lineStartProhibited = ")]}!?,.:;" +
+	"）］｝〕〉》」』】〙〗〟’”｠»" +
+	"、。，．・：；？！‼⁇⁈⁉゛゜ヽヾゝゞ々〻ー‐゠–〜～" +
+	"ぁぃぅぇぉっゃゅょゎゕゖァィゥェォッャュョヮヵヶㇰㇱㇲㇳㇴㇵㇶㇷㇸㇹㇺㇻㇼㇽㇾㇿ"
+
+// 行末禁則文字
+const lineEndProhibited = "([{（［｛〔〈《「『【〘〖〝‘“｟«"
+
+// ぶら下げ文字
+const hangingPunctuation = "、。，．,."
+
+// 折り返し位置：行の終わり（末尾の空白を除く）と次の行の始まり
+func nextLineBreak(glyphs []textGlyph, start int, width float64) (int, int) {
+	var lineWidth float64
+	var lastBreak = -1
+	for i := start; i < len(glyphs); i++ {
+		if i > start && canBreakBefore(glyphs[i-1].r, glyphs[i].r) {
+			lastBreak = i
+		}
+
+		// 空白は行末にはみ出しても折り返さない
+		if unicode.IsSpace(glyphs[i].r) {
+			lineWidth += glyphs[i].width
+			continue
+		}
+
+		if i > start && lineWidth+glyphs[i].width > width {
+			// ぶら下げ：句読点は行末にはみ出して置く
+			if strings.ContainsRune(hangingPunctuation, glyphs[i].r) && (i+1 == len(glyphs) || canBreakBefore(glyphs[i].r, glyphs[i+1].r)) {
+				return trimLineEnd(glyphs, start, i+1), skipSpaces(glyphs, i+1)
+			}
+			// 追い出し：最後に折り返せる位置で改行する
+			if lastBreak > start {
+				return trimLineEnd(glyphs, start, lastBreak), skipSpaces(glyphs, lastBreak)
+			}
+			// 折り返せる位置がない長い単語は文字の途中で改行する
+			return i, i
+		}
+		lineWidth += glyphs[i].width
+	}
+	return trimLineEnd(glyphs, start, len(glyphs)), len(glyphs)
+}
+
+// 判定：文字の間で改行できる
+func canBreakBefore(prev rune, r rune) bool {
+	if strings.ContainsRune(lineStartProhibited, r) || strings.ContainsRune(lineEndProhibited, prev) {
+		return false
+	}
+	if unicode.IsSpace(r) {
+		return false
+	}
+	if unicode.IsSpace(prev) || prev == '-' {
+		return true
+	}
+	// 欧文は単語の途中で改行しない
+	return isCJK(prev) || isCJK(r)
+}
+
+// 判定：和文（単語の区切りに関係なく改行できる文字）
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		(r >= 0x3000 && r <= 0x303F) || // CJK Symbols and Punctuation
+		(r >= 0xFF00 && r <= 0xFFEF) // Halfwidth and Fullwidth Forms
+}
+
+func trimLineEnd(glyphs []textGlyph, start int, end int) int {
+	for end > start && unicode.IsSpace(glyphs[end-1].r) {
+		end--
+	}
+	return end
+}
+
+func skipSpaces(glyphs []textGlyph, start int) int {
+	for start < len(glyphs) && unicode.IsSpace(glyphs[start].r) {
+		start++
+	}
+	return start
+}
@@ -0,0 +1,77 @@
+package pdf
+
+import "testing"
+
+// 1 文字の幅を 1 とした文字列
+func testGlyphs(text string) []textGlyph {
+	var glyphs []textGlyph
+	for _, r := range text {
+		glyphs = append(glyphs, textGlyph{r: r, width: 1})
+	}
+	return glyphs
+}
+
+func TestNextLineBreak(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		start    int
+		width    float64
+		wantEnd  int
+		wantNext int
+	}{
+		{name: "fits", text: "abc", width: 10, wantEnd: 3, wantNext: 3},
+		{name: "word wrap", text: "hello world", width: 8, wantEnd: 5, wantNext: 6},
+		{name: "from start", text: "hello world", start: 6, width: 8, wantEnd: 11, wantNext: 11},
+		{name: "trailing spaces", text: "ab   cd", width: 3, wantEnd: 2, wantNext: 5},
+		{name: "after hyphen", text: "well-known", width: 7, wantEnd: 5, wantNext: 5},
+		{name: "long word", text: "abcdefghij", width: 4, wantEnd: 4, wantNext: 4},
+		{name: "cjk", text: "あいうえおか", width: 4, wantEnd: 4, wantNext: 4},
+		{name: "hanging at end", text: "あいうえ。", width: 4, wantEnd: 5, wantNext: 5},
+		{name: "hanging in middle", text: "あいうえ、お", width: 4, wantEnd: 5, wantNext: 5},
+		{name: "line start prohibited", text: "あいうえっお", width: 4, wantEnd: 3, wantNext: 3},
+		{name: "long vowel mark", text: "あいうえーお", width: 4, wantEnd: 3, wantNext: 3},
+		{name: "closing bracket", text: "あいうえ」お", width: 4, wantEnd: 3, wantNext: 3},
+		{name: "line end prohibited", text: "あいう「えお", width: 4, wantEnd: 3, wantNext: 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			end, next := nextLineBreak(testGlyphs(test.text), test.start, test.width)
+			if end != test.wantEnd || next != test.wantNext {
+				t.Errorf("nextLineBreak(%q, %d, %v) = (%d, %d), want (%d, %d)", test.text, test.start, test.width, end, next, test.wantEnd, test.wantNext)
+			}
+		})
+	}
+}
+
+func TestCanBreakBefore(t *testing.T) {
+	tests := []struct {
+		prev rune
+		r    rune
+		want bool
+	}{
+		{prev: 'a', r: 'b', want: false},
+		{prev: ' ', r: 'b', want: true},
+		{prev: 'a', r: ' ', want: false},
+		{prev: '-', r: 'b', want: true},
+		{prev: 'a', r: 'あ', want: true},
+		{prev: 'あ', r: 'い', want: true},
+		{prev: '漢', r: '字', want: true},
+		{prev: 'あ', r: '。', want: false},
+		{prev: 'あ', r: '、', want: false},
+		{prev: 'あ', r: 'ゃ', want: false},
+		{prev: 'ア', r: 'ー', want: false},
+		{prev: 'あ', r: '）', want: false},
+		{prev: '（', r: 'あ', want: false},
+		{prev: '「', r: 'あ', want: false},
+		{prev: 'a', r: ')', want: false},
+		{prev: '(', r: 'a', want: false},
+	}
+	for _, test := range tests {
+		t.Run(string([]rune{test.prev, test.r}), func(t *testing.T) {
+			if got := canBreakBefore(test.prev, test.r); got != test.want {
+				t.Errorf("canBreakBefore(%q, %q) = %v, want %v", test.prev, test.r, got, test.want)
+			}
+		})
+	}
+}
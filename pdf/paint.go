@@ -0,0 +1,454 @@
+package pdf
+
+import (
+	"apple-x-co/go-pdf/types"
+	"github.com/signintech/gopdf"
+	"image"
+	"image/color"
+	"math"
+)
+
+// 描画：不透明度（0〜1）を指定して描画（入れ子の場合は外側の不透明度を掛け合わせ、描画後は外側の透明度に戻す）
+func (p *PDF) drawWithAlpha(alpha float64, draw func() error) error {
+	if alpha >= 1 {
+		return draw()
+	}
+	outer := p.transparency
+	if outer != nil {
+		alpha *= outer.Alpha
+	}
+	transparency, err := gopdf.NewTransparency(math.Max(alpha, 0), "")
+	if err != nil {
+		return err
+	}
+
+	// 透明度は後の描画に残らないように描画状態を戻す
+	p.saveState()
+	defer p.restoreState()
+	if err := p.gp.SetTransparency(transparency); err != nil {
+		return err
+	}
+	p.transparency = &transparency
+	defer func() {
+		p.transparency = outer
+		if outer != nil {
+			_ = p.gp.SetTransparency(*outer)
+		} else {
+			p.gp.ClearTransparency()
+		}
+	}()
+	return draw()
+}
+
+// 描画：塗りつぶし
+func (p *PDF) fillRect(color types.Color, rect types.Rect) error {
+	return p.drawWithAlpha(color.Alpha(), func() error {
+		p.setFillColor(color)
+		p.gp.RectFromUpperLeftWithStyle(rect.MinX(), rect.MinY(), rect.Width(), rect.Height(), "F")
+		return nil
+	})
+}
+
+// 描画：線
+func (p *PDF) strokeLine(border types.Border, x1 float64, y1 float64, x2 float64, y2 float64) error {
+	return p.drawWithAlpha(border.Color.Alpha(), func() error {
+		if border.IsPlain() {
+			p.gp.SetLineWidth(border.Width)
+			p.setStrokeColor(border.Color)
+			p.gp.Line(x1, y1, x2, y2)
+			return nil
+		}
+
+		p.setFillColor(border.Color)
+		if border.Style.IsDouble() {
+			// 二重線：太さを 3 等分して両側に線を引く
+			length := math.Hypot(x2-x1, y2-y1)
+			if length == 0 {
+				return nil
+			}
+			offsetX, offsetY := -(y2-y1)/length*border.Width/3, (x2-x1)/length*border.Width/3
+			p.fillDashedLine(x1+offsetX, y1+offsetY, x2+offsetX, y2+offsetY, border.Width/3, border.DashPattern(), border.Cap())
+			p.fillDashedLine(x1-offsetX, y1-offsetY, x2-offsetX, y2-offsetY, border.Width/3, border.DashPattern(), border.Cap())
+			return nil
+		}
+		p.fillDashedLine(x1, y1, x2, y2, border.Width, border.DashPattern(), border.Cap())
+		return nil
+	})
+}
+
+// 描画：角丸の塗りつぶし
+func (p *PDF) fillRoundedRect(color types.Color, rect types.Rect, radius types.BorderRadius) error {
+	if radius.IsZero() {
+		return p.fillRect(color, rect)
+	}
+	return p.drawWithAlpha(color.Alpha(), func() error {
+		p.setFillColor(color)
+		p.gp.Polygon(roundedRectPoints(rect, radius.Fit(rect.Size)), "F")
+		return nil
+	})
+}
+
+// 描画：枠線（radius を指定すると角丸）
+func (p *PDF) drawFrameBorder(border types.Border, frame types.Rect, radius types.BorderRadius) error {
+	if !radius.IsZero() {
+		return p.drawRoundedFrameBorder(border, frame, radius.Fit(frame.Size))
+	}
+	return p.drawWithAlpha(border.Color.Alpha(), func() error {
+		if border.IsPlain() {
+			p.gp.SetLineWidth(border.Width)
+			p.setStrokeColor(border.Color)
+			p.gp.RectFromUpperLeftWithStyle(frame.MinX(), frame.MinY(), frame.Width(), frame.Height(), "D")
+			return nil
+		}
+
+		if border.Style.IsDouble() {
+			p.drawDoubleFrameBorder(border, frame, types.BorderRadius{})
+			return nil
+		}
+
+		p.setFillColor(border.Color)
+		corners := []types.Origin{
+			{X: frame.MinX(), Y: frame.MinY()},
+			{X: frame.MaxX(), Y: frame.MinY()},
+			{X: frame.MaxX(), Y: frame.MaxY()},
+			{X: frame.MinX(), Y: frame.MaxY()},
+		}
+		pattern := border.DashPattern()
+		for i, corner := range corners {
+			next := corners[(i+1)%len(corners)]
+			p.fillDashedLine(corner.X, corner.Y, next.X, next.Y, border.Width, pattern, border.Cap())
+		}
+		if pattern != nil {
+			return nil
+		}
+
+		// 角：辺の外側に空く部分を角の形で埋める
+		half := border.Width / 2
+		for i, corner := range corners {
+			outX, outY := -half, -half
+			if i == 1 || i == 2 {
+				outX = half
+			}
+			if i == 2 || i == 3 {
+				outY = half
+			}
+			if border.LineJoin.IsRound() {
+				p.fillLine(corner.X, corner.Y, corner.X, corner.Y, border.Width, types.LineCapRound)
+			} else if border.LineJoin.IsBevel() {
+				p.gp.Polygon([]gopdf.Point{{X: corner.X, Y: corner.Y}, {X: corner.X + outX, Y: corner.Y}, {X: corner.X, Y: corner.Y + outY}}, "F")
+			} else {
+				p.gp.Polygon([]gopdf.Point{{X: corner.X, Y: corner.Y}, {X: corner.X + outX, Y: corner.Y}, {X: corner.X + outX, Y: corner.Y + outY}, {X: corner.X, Y: corner.Y + outY}}, "F")
+			}
+		}
+		return nil
+	})
+}
+
+// 描画：角丸の枠線
+func (p *PDF) drawRoundedFrameBorder(border types.Border, frame types.Rect, radius types.BorderRadius) error {
+	return p.drawWithAlpha(border.Color.Alpha(), func() error {
+		pattern := border.DashPattern()
+		if border.Style.IsDouble() {
+			p.drawDoubleFrameBorder(border, frame, radius)
+			return nil
+		}
+		if pattern == nil {
+			p.gp.SetLineWidth(border.Width)
+			p.setStrokeColor(border.Color)
+			p.gp.Polygon(roundedRectPoints(frame, radius), "D")
+			return nil
+		}
+
+		points := roundedRectPoints(frame, radius)
+		p.setFillColor(border.Color)
+		p.fillDashedPath(append(points, points[0]), border.Width, pattern, border.Cap())
+		return nil
+	})
+}
+
+// 描画：二重線の枠（太さを 3 等分して外側と内側に枠を引く、dash を指定した場合は両方を破線にする）
+func (p *PDF) drawDoubleFrameBorder(border types.Border, frame types.Rect, radius types.BorderRadius) {
+	third := border.Width / 3
+	pattern := border.DashPattern()
+	outer := types.Rect{Origin: types.Origin{X: frame.MinX() - third, Y: frame.MinY() - third}, Size: types.Size{Width: frame.Width() + third*2, Height: frame.Height() + third*2}}
+	inner := types.Rect{Origin: types.Origin{X: frame.MinX() + third, Y: frame.MinY() + third}, Size: types.Size{Width: frame.Width() - third*2, Height: frame.Height() - third*2}}
+	for _, points := range [][]gopdf.Point{roundedRectPoints(outer, radius.Expand(third)), roundedRectPoints(inner, radius.Expand(-third))} {
+		if pattern == nil {
+			p.gp.SetLineWidth(third)
+			p.setStrokeColor(border.Color)
+			p.gp.Polygon(points, "D")
+			continue
+		}
+		p.setFillColor(border.Color)
+		p.fillDashedPath(append(points, points[0]), third, pattern, border.Cap())
+	}
+}
+
+// 角丸の四角形の輪郭（左上から時計回り、円弧は折れ線で近似する）
+func roundedRectPoints(rect types.Rect, radius types.BorderRadius) []gopdf.Point {
+	const arcSteps = 8
+	corners := []struct {
+		radius float64
+		x      float64
+		y      float64
+		angle  float64
+	}{
+		{radius.TopLeft, rect.MinX() + radius.TopLeft, rect.MinY() + radius.TopLeft, math.Pi},
+		{radius.TopRight, rect.MaxX() - radius.TopRight, rect.MinY() + radius.TopRight, math.Pi * 3 / 2},
+		{radius.BottomRight, rect.MaxX() - radius.BottomRight, rect.MaxY() - radius.BottomRight, 0},
+		{radius.BottomLeft, rect.MinX() + radius.BottomLeft, rect.MaxY() - radius.BottomLeft, math.Pi / 2},
+	}
+
+	var points []gopdf.Point
+	for _, corner := range corners {
+		if corner.radius <= 0 {
+			points = append(points, gopdf.Point{X: corner.x, Y: corner.y})
+			continue
+		}
+		for i := 0; i <= arcSteps; i++ {
+			theta := corner.angle + math.Pi/2*float64(i)/arcSteps
+			points = append(points, gopdf.Point{X: corner.x + corner.radius*math.Cos(theta), Y: corner.y + corner.radius*math.Sin(theta)})
+		}
+	}
+	return points
+}
+
+// 角丸の外側を透明にした画像（imageRect は画像を描画する位置、clipRect は切り抜く形の位置、境界は 1 ピクセル分をなめらかにする）
+func clipRoundedImage(img image.Image, imageRect types.Rect, clipRect types.Rect, radius types.BorderRadius) *image.NRGBA {
+	bounds := img.Bounds()
+	clipped := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	scaleX, scaleY := imageRect.Width()/float64(bounds.Dx()), imageRect.Height()/float64(bounds.Dy())
+	pixel := math.Max(scaleX, scaleY)
+
+	for py := 0; py < bounds.Dy(); py++ {
+		for px := 0; px < bounds.Dx(); px++ {
+			c := color.NRGBAModel.Convert(img.At(bounds.Min.X+px, bounds.Min.Y+py)).(color.NRGBA)
+			x, y := imageRect.MinX()+(float64(px)+0.5)*scaleX, imageRect.MinY()+(float64(py)+0.5)*scaleY
+			c.A = uint8(math.Round(float64(c.A) * roundedRectCoverage(x, y, clipRect, radius, pixel)))
+			clipped.SetNRGBA(px, py, c)
+		}
+	}
+	return clipped
+}
+
+// 角丸の四角形が点 (x, y) の 1 ピクセル（pixel は 1 ピクセルの大きさ）を覆う割合（0〜1）
+func roundedRectCoverage(x float64, y float64, rect types.Rect, radius types.BorderRadius, pixel float64) float64 {
+	coverage := math.Min(math.Min(x-rect.MinX(), rect.MaxX()-x), math.Min(y-rect.MinY(), rect.MaxY()-y))/pixel + 0.5
+	corners := []struct {
+		radius float64
+		x      float64
+		y      float64
+	}{
+		{radius.TopLeft, rect.MinX() + radius.TopLeft, rect.MinY() + radius.TopLeft},
+		{radius.TopRight, rect.MaxX() - radius.TopRight, rect.MinY() + radius.TopRight},
+		{radius.BottomRight, rect.MaxX() - radius.BottomRight, rect.MaxY() - radius.BottomRight},
+		{radius.BottomLeft, rect.MinX() + radius.BottomLeft, rect.MaxY() - radius.BottomLeft},
+	}
+	for i, corner := range corners {
+		if corner.radius <= 0 {
+			continue
+		}
+		outsideX := (i == 0 || i == 3) && x < corner.x || (i == 1 || i == 2) && x > corner.x
+		outsideY := (i == 0 || i == 1) && y < corner.y || (i == 2 || i == 3) && y > corner.y
+		if outsideX && outsideY {
+			coverage = math.Min(coverage, (corner.radius-math.Hypot(x-corner.x, y-corner.y))/pixel+0.5)
+		}
+	}
+	return math.Min(math.Max(coverage, 0), 1)
+}
+
+// 描画：破線（pattern は線の長さ・間隔の繰り返し、nil の場合は実線）
+func (p *PDF) fillDashedLine(x1 float64, y1 float64, x2 float64, y2 float64, width float64, pattern []float64, lineCap types.LineCap) {
+	p.fillDashedPath([]gopdf.Point{{X: x1, Y: y1}, {X: x2, Y: y2}}, width, pattern, lineCap)
+}
+
+// 描画：折れ線に沿った破線（破線の途中の折れ目は丸めてつなぐ）
+func (p *PDF) fillDashedPath(points []gopdf.Point, width float64, pattern []float64, lineCap types.LineCap) {
+	for _, dash := range dashedPaths(points, pattern) {
+		p.fillPolyline(dash, width, lineCap)
+	}
+}
+
+// 分割：折れ線を破線の線ごとの折れ線に分ける（pattern が実線の場合は折れ線をそのまま返す）
+func dashedPaths(points []gopdf.Point, pattern []float64) [][]gopdf.Point {
+	var lengths []float64
+	var total float64
+	for i := 1; i < len(points); i++ {
+		length := math.Hypot(points[i].X-points[i-1].X, points[i].Y-points[i-1].Y)
+		lengths = append(lengths, length)
+		total += length
+	}
+	var cycle float64
+	for _, value := range pattern {
+		cycle += math.Max(value, 0)
+	}
+	if cycle == 0 || total == 0 {
+		return [][]gopdf.Point{points}
+	}
+
+	// 距離 distance の位置の点と、その点が含まれる区間
+	pointAt := func(distance float64) (gopdf.Point, int) {
+		for i, length := range lengths {
+			if distance <= length || i == len(lengths)-1 {
+				ratio := 0.0
+				if length > 0 {
+					ratio = math.Min(distance/length, 1)
+				}
+				return gopdf.Point{X: points[i].X + (points[i+1].X-points[i].X)*ratio, Y: points[i].Y + (points[i+1].Y-points[i].Y)*ratio}, i
+			}
+			distance -= length
+		}
+		return points[len(points)-1], len(lengths) - 1
+	}
+
+	// 誤差で終点に長さ 0 の破線が残らないように許容する差
+	const epsilon = 1e-9
+
+	var dashes [][]gopdf.Point
+	position := 0.0
+	for i := 0; position <= total+epsilon; i++ {
+		value := math.Max(pattern[i%len(pattern)], 0)
+		if i%2 == 0 {
+			// 終点から始まる線は点線の点だけを描く（線端が丸・四角の場合に点が残らないように）
+			if value > 0 && position >= total-epsilon {
+				break
+			}
+			start, startIndex := pointAt(position)
+			end, endIndex := pointAt(math.Min(position+value, total))
+			dash := []gopdf.Point{start}
+			for j := startIndex + 1; j <= endIndex; j++ {
+				dash = append(dash, points[j])
+			}
+			dashes = append(dashes, append(dash, end))
+		}
+		position += value
+	}
+	return dashes
+}
+
+// 描画：太さのある折れ線（折れ目は丸めてつなぐ）
+func (p *PDF) fillPolyline(points []gopdf.Point, width float64, lineCap types.LineCap) {
+	if len(points) == 2 {
+		p.fillLine(points[0].X, points[0].Y, points[1].X, points[1].Y, width, lineCap)
+		return
+	}
+	for i := 1; i < len(points); i++ {
+		p.fillLine(points[i-1].X, points[i-1].Y, points[i].X, points[i].Y, width, types.LineCapButt)
+		if i < len(points)-1 {
+			p.fillLine(points[i].X, points[i].Y, points[i].X, points[i].Y, width, types.LineCapRound)
+		}
+	}
+
+	// 線端
+	first, second := points[0], points[1]
+	last, beforeLast := points[len(points)-1], points[len(points)-2]
+	if lineCap.IsRound() {
+		p.fillLine(first.X, first.Y, first.X, first.Y, width, types.LineCapRound)
+		p.fillLine(last.X, last.Y, last.X, last.Y, width, types.LineCapRound)
+	} else if lineCap.IsSquare() {
+		half := width / 2
+		if length := math.Hypot(second.X-first.X, second.Y-first.Y); length > 0 {
+			p.fillLine(first.X, first.Y, first.X-(second.X-first.X)/length*half, first.Y-(second.Y-first.Y)/length*half, width, types.LineCapButt)
+		}
+		if length := math.Hypot(last.X-beforeLast.X, last.Y-beforeLast.Y); length > 0 {
+			p.fillLine(last.X, last.Y, last.X+(last.X-beforeLast.X)/length*half, last.Y+(last.Y-beforeLast.Y)/length*half, width, types.LineCapButt)
+		}
+	}
+}
+
+// 描画：太さのある線を塗りつぶしで描く（線端の形を指定できるように多角形にする）
+func (p *PDF) fillLine(x1 float64, y1 float64, x2 float64, y2 float64, width float64, lineCap types.LineCap) {
+	const arcSteps = 8
+	half := width / 2
+	length := math.Hypot(x2-x1, y2-y1)
+	if length == 0 && lineCap.IsButt() {
+		return
+	}
+	angle := math.Atan2(y2-y1, x2-x1)
+	unitX, unitY := math.Cos(angle), math.Sin(angle)
+
+	var points []gopdf.Point
+	if lineCap.IsRound() {
+		for i := 0; i <= arcSteps; i++ {
+			theta := angle - math.Pi/2 + math.Pi*float64(i)/arcSteps
+			points = append(points, gopdf.Point{X: x2 + half*math.Cos(theta), Y: y2 + half*math.Sin(theta)})
+		}
+		for i := 0; i <= arcSteps; i++ {
+			theta := angle + math.Pi/2 + math.Pi*float64(i)/arcSteps
+			points = append(points, gopdf.Point{X: x1 + half*math.Cos(theta), Y: y1 + half*math.Sin(theta)})
+		}
+	} else {
+		if lineCap.IsSquare() {
+			x1, y1 = x1-unitX*half, y1-unitY*half
+			x2, y2 = x2+unitX*half, y2+unitY*half
+		}
+		normalX, normalY := -unitY*half, unitX*half
+		points = []gopdf.Point{
+			{X: x1 + normalX, Y: y1 + normalY},
+			{X: x2 + normalX, Y: y2 + normalY},
+			{X: x2 - normalX, Y: y2 - normalY},
+			{X: x1 - normalX, Y: y1 - normalY},
+		}
+	}
+	p.gp.Polygon(points, "F")
+}
+
+// 描画：上下左右の枠線
+func (p *PDF) drawFrameBorders(borderTop types.Border, borderRight types.Border, borderBottom types.Border, borderLeft types.Border, frame types.Rect) error {
+	if borderTop.Width != UnsetWidth {
+		if err := p.strokeLine(borderTop, frame.MinX(), frame.MinY(), frame.MaxX(), frame.MinY()); err != nil {
+			return err
+		}
+	}
+	if borderRight.Width != UnsetWidth {
+		if err := p.strokeLine(borderRight, frame.MaxX(), frame.MinY(), frame.MaxX(), frame.MaxY()); err != nil {
+			return err
+		}
+	}
+	if borderBottom.Width != UnsetWidth {
+		if err := p.strokeLine(borderBottom, frame.MaxX(), frame.MaxY(), frame.MinX(), frame.MaxY()); err != nil {
+			return err
+		}
+	}
+	if borderLeft.Width != UnsetWidth {
+		if err := p.strokeLine(borderLeft, frame.MinX(), frame.MaxY(), frame.MinX(), frame.MinY()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 色空間：CMYK で指定した色、または文書の色空間が CMYK の場合は CMYK で描画する
+func (p *PDF) cmykColor(color types.Color) (types.CMYK, bool) {
+	if color.CMYK == nil && !p.colorSpace.IsCMYK() {
+		return types.CMYK{}, false
+	}
+	return color.ToCMYK(), true
+}
+
+// 色の設定：文字色
+func (p *PDF) setTextColor(color types.Color) {
+	if cmyk, ok := p.cmykColor(color); ok {
+		p.gp.SetTextColorCMYK(cmyk.C, cmyk.M, cmyk.Y, cmyk.K)
+		return
+	}
+	p.gp.SetTextColor(color.R, color.G, color.B)
+}
+
+// 色の設定：塗りつぶし色
+func (p *PDF) setFillColor(color types.Color) {
+	if cmyk, ok := p.cmykColor(color); ok {
+		p.gp.SetFillColorCMYK(cmyk.C, cmyk.M, cmyk.Y, cmyk.K)
+		return
+	}
+	p.gp.SetFillColor(color.R, color.G, color.B)
+}
+
+// 色の設定：線の色
+func (p *PDF) setStrokeColor(color types.Color) {
+	if cmyk, ok := p.cmykColor(color); ok {
+		p.gp.SetStrokeColorCMYK(cmyk.C, cmyk.M, cmyk.Y, cmyk.K)
+		return
+	}
+	p.gp.SetStrokeColor(color.R, color.G, color.B)
+}
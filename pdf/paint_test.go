@@ -0,0 +1,207 @@
+package pdf
+
+import (
+	"apple-x-co/go-pdf/types"
+	"bytes"
+	"github.com/signintech/gopdf"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestFillColorSpace(t *testing.T) {
+	alpha := 0.5
+	tests := []struct {
+		name       string
+		colorSpace types.ColorSpace
+		color      types.Color
+		want       string
+	}{
+		{name: "rgb", colorSpace: types.ColorSpaceRGB, color: types.Color{R: 255}, want: "1.000 0.000 0.000 rg"},
+		{name: "cmyk color", colorSpace: types.ColorSpaceRGB, color: types.Color{CMYK: &types.CMYK{C: 10, M: 20, Y: 30, K: 40}}, want: "0.10 0.20 0.30 0.40 k"},
+		{name: "cmyk color space", colorSpace: types.ColorSpaceCMYK, color: types.Color{R: 255}, want: "0.00 1.00 1.00 0.00 k"},
+		{name: "cmyk with alpha", colorSpace: types.ColorSpaceCMYK, color: types.Color{R: 255, A: &alpha}, want: "0.00 1.00 1.00 0.00 k"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newTestPDF(t)
+			p.colorSpace = test.colorSpace
+			if err := p.fillRect(test.color, types.Rect{Size: types.Size{Width: 10, Height: 10}}); err != nil {
+				t.Fatal(err)
+			}
+			if got := p.gp.GetBytesPdf(); !bytes.Contains(got, []byte(test.want)) {
+				t.Errorf("fillRect() does not write %q", test.want)
+			}
+		})
+	}
+}
+
+func TestDashedPaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		points  []gopdf.Point
+		pattern []float64
+		want    [][]gopdf.Point
+	}{
+		{
+			name:   "solid",
+			points: []gopdf.Point{{X: 0, Y: 0}, {X: 10, Y: 0}},
+			want:   [][]gopdf.Point{{{X: 0, Y: 0}, {X: 10, Y: 0}}},
+		},
+		{
+			name:    "zero pattern",
+			points:  []gopdf.Point{{X: 0, Y: 0}, {X: 10, Y: 0}},
+			pattern: []float64{0, 0},
+			want:    [][]gopdf.Point{{{X: 0, Y: 0}, {X: 10, Y: 0}}},
+		},
+		{
+			name:    "dashed",
+			points:  []gopdf.Point{{X: 0, Y: 0}, {X: 10, Y: 0}},
+			pattern: []float64{3, 2},
+			want: [][]gopdf.Point{
+				{{X: 0, Y: 0}, {X: 3, Y: 0}},
+				{{X: 5, Y: 0}, {X: 8, Y: 0}},
+			},
+		},
+		{
+			name:    "dashed ending in gap",
+			points:  []gopdf.Point{{X: 0, Y: 0}, {X: 12, Y: 0}},
+			pattern: []float64{3, 2},
+			want: [][]gopdf.Point{
+				{{X: 0, Y: 0}, {X: 3, Y: 0}},
+				{{X: 5, Y: 0}, {X: 8, Y: 0}},
+				{{X: 10, Y: 0}, {X: 12, Y: 0}},
+			},
+		},
+		{
+			name:    "fractional dashed",
+			points:  []gopdf.Point{{X: 0, Y: 0}, {X: 0.6, Y: 0}},
+			pattern: []float64{0.1, 0.2},
+			want: [][]gopdf.Point{
+				{{X: 0, Y: 0}, {X: 0.1, Y: 0}},
+				{{X: 0.30000000000000004, Y: 0}, {X: 0.4, Y: 0}},
+			},
+		},
+		{
+			name:    "dotted",
+			points:  []gopdf.Point{{X: 0, Y: 0}, {X: 0, Y: 4}},
+			pattern: []float64{0, 2},
+			want: [][]gopdf.Point{
+				{{X: 0, Y: 0}, {X: 0, Y: 0}},
+				{{X: 0, Y: 2}, {X: 0, Y: 2}},
+				{{X: 0, Y: 4}, {X: 0, Y: 4}},
+			},
+		},
+		{
+			name:    "dash across corner",
+			points:  []gopdf.Point{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 4}},
+			pattern: []float64{6, 2},
+			want: [][]gopdf.Point{
+				{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 2}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := dashedPaths(test.points, test.pattern); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("dashedPaths() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBorderDashPattern(t *testing.T) {
+	tests := []struct {
+		name        string
+		border      types.Border
+		wantPattern []float64
+		wantCap     types.LineCap
+	}{
+		{name: "solid", border: types.Border{Width: 2}, wantPattern: nil, wantCap: ""},
+		{name: "dashed", border: types.Border{Width: 2, Style: types.BorderStyleDashed}, wantPattern: []float64{6, 4}, wantCap: ""},
+		{name: "dotted", border: types.Border{Width: 2, Style: types.BorderStyleDotted}, wantPattern: []float64{0, 4}, wantCap: types.LineCapRound},
+		{name: "dotted square", border: types.Border{Width: 2, Style: types.BorderStyleDotted, LineCap: types.LineCapSquare}, wantPattern: []float64{0, 4}, wantCap: types.LineCapSquare},
+		{name: "dash", border: types.Border{Width: 2, Style: types.BorderStyleDashed, Dash: []float64{1, 1}}, wantPattern: []float64{1, 1}, wantCap: ""},
+		{name: "double dash", border: types.Border{Width: 2, Style: types.BorderStyleDouble, Dash: []float64{5, 1}}, wantPattern: []float64{5, 1}, wantCap: ""},
+		{name: "double", border: types.Border{Width: 2, Style: types.BorderStyleDouble}, wantPattern: nil, wantCap: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.border.DashPattern(); !reflect.DeepEqual(got, test.wantPattern) {
+				t.Errorf("DashPattern() = %v, want %v", got, test.wantPattern)
+			}
+			if got := test.border.Cap(); got != test.wantCap {
+				t.Errorf("Cap() = %q, want %q", got, test.wantCap)
+			}
+		})
+	}
+}
+
+func TestRoundedRectPoints(t *testing.T) {
+	rect := types.Rect{Origin: types.Origin{X: 10, Y: 20}, Size: types.Size{Width: 30, Height: 40}}
+	tests := []struct {
+		name   string
+		radius types.BorderRadius
+		want   map[int]gopdf.Point
+		length int
+	}{
+		{
+			name:   "square",
+			radius: types.BorderRadius{},
+			want:   map[int]gopdf.Point{0: {X: 10, Y: 20}, 1: {X: 40, Y: 20}, 2: {X: 40, Y: 60}, 3: {X: 10, Y: 60}},
+			length: 4,
+		},
+		{
+			name:   "rounded",
+			radius: types.BorderRadius{TopLeft: 5, TopRight: 5, BottomRight: 5, BottomLeft: 5},
+			want:   map[int]gopdf.Point{0: {X: 10, Y: 25}, 8: {X: 15, Y: 20}, 9: {X: 35, Y: 20}, 17: {X: 40, Y: 25}, 26: {X: 35, Y: 60}, 35: {X: 10, Y: 55}},
+			length: 36,
+		},
+		{
+			name:   "top left only",
+			radius: types.BorderRadius{TopLeft: 5},
+			want:   map[int]gopdf.Point{0: {X: 10, Y: 25}, 8: {X: 15, Y: 20}, 9: {X: 40, Y: 20}, 10: {X: 40, Y: 60}, 11: {X: 10, Y: 60}},
+			length: 12,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := roundedRectPoints(rect, test.radius)
+			if len(got) != test.length {
+				t.Fatalf("len(roundedRectPoints()) = %d, want %d", len(got), test.length)
+			}
+			for i, want := range test.want {
+				if math.Abs(got[i].X-want.X) > 1e-9 || math.Abs(got[i].Y-want.Y) > 1e-9 {
+					t.Errorf("roundedRectPoints()[%d] = %v, want %v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRoundedRectCoverage(t *testing.T) {
+	rect := types.Rect{Size: types.Size{Width: 10, Height: 10}}
+	radius := types.BorderRadius{TopLeft: 4, TopRight: 4, BottomRight: 4, BottomLeft: 4}
+	arc := 4 - 4*math.Sqrt2/2
+	tests := []struct {
+		name   string
+		x, y   float64
+		radius types.BorderRadius
+		want   float64
+	}{
+		{name: "center", x: 5, y: 5, radius: radius, want: 1},
+		{name: "edge", x: 0, y: 5, radius: radius, want: 0.5},
+		{name: "outside", x: -1, y: 5, radius: radius, want: 0},
+		{name: "outside corner", x: 0.5, y: 0.5, radius: radius, want: 0},
+		{name: "on arc", x: arc, y: arc, radius: radius, want: 0.5},
+		{name: "inside arc", x: 9, y: 9, radius: types.BorderRadius{}, want: 1},
+		{name: "square corner", x: 0.5, y: 0.5, radius: types.BorderRadius{TopRight: 4}, want: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := roundedRectCoverage(test.x, test.y, rect, test.radius, 1); math.Abs(got-test.want) > 1e-9 {
+				t.Errorf("roundedRectCoverage(%v, %v) = %v, want %v", test.x, test.y, got, test.want)
+			}
+		})
+	}
+}
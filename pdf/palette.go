@@ -0,0 +1,30 @@
+package pdf
+
+import (
+	"apple-x-co/go-pdf/types"
+	"encoding/json"
+)
+
+// 名前で指定した色をパレットの色に置き換えられる属性
+type paletteResolver interface {
+	ResolvePalette(palette map[string]types.Color) error
+}
+
+// 読み込み：要素の属性（属性がない場合は初期値のまま）
+func (p *PDF) decodeAttributes(attributes json.RawMessage, decoded interface{}, path string) error {
+	if len(attributes) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(attributes, decoded); err != nil {
+		return &AttributeDecodeError{PageIndex: p.pageIndex(), Path: path, Err: err}
+	}
+	return nil
+}
+
+// パレット：要素の属性の名前で指定した色をパレットの色に置き換える
+func (p *PDF) resolvePalette(decoded paletteResolver, path string) error {
+	if err := decoded.ResolvePalette(p.palette); err != nil {
+		return &AttributeDecodeError{PageIndex: p.pageIndex(), Path: path, Err: err}
+	}
+	return nil
+}
@@ -3,18 +3,16 @@ package pdf
 import (
 	"apple-x-co/go-pdf/types"
 	"bytes"
-	"encoding/json"
+	"context"
+	"fmt"
 	"github.com/nfnt/resize"
 	"github.com/signintech/gopdf"
-	"github.com/signintech/gopdf/fontmaker/core"
 	"image"
 	"image/jpeg"
 	"image/png"
-	"log"
+	"io"
 	"math"
 	"os"
-	"strings"
-	"text/template"
 	"time"
 )
 
@@ -26,20 +24,83 @@ const DefaultColorR uint8 = 0
 const DefaultColorG uint8 = 0
 const DefaultColorB uint8 = 0
 const DefaultTextSize int = 14
+const DefaultMinTextSize int = 6
 const DefaultCompressLevel int = -1
 const DefaultImageResolution uint = 2
+const MaxLayoutPasses int = 3
 
 type PDF struct {
 	gp               gopdf.GoPdf
 	contentRect      types.Rect
 	commonHeaderRect types.Rect
 	commonFooterRect types.Rect
-	templates        map[string]interface{}
+	templates        map[string]types.ElementTemplate
 	pageNumber       uint
+	pagePath         string
+	fonts            map[string]font
+	fallbackFonts    []string
+	glyphs           map[string]map[rune]bool
+	sectionIndex     int
+	sectionStart     uint
+	sectionPages     []uint
+	totalPages       uint
+	sectionTotals    []uint
+	usesTotalPages   bool
+	now              time.Time
+	data             interface{}
+	item             interface{}
+	index            int
+	ctx              context.Context
+	transparency     *gopdf.Transparency
+	colorSpace       types.ColorSpace
+	palette          map[string]types.Color
+	pageTop          float64
 }
 
-func (p *PDF) Draw(documentConfigure types.DocumentConfigure) {
+func (p *PDF) Draw(documentConfigure types.DocumentConfigure) error {
+	return p.DrawContext(context.Background(), documentConfigure)
+}
+
+func (p *PDF) DrawContext(ctx context.Context, documentConfigure types.DocumentConfigure) error {
+	p.ctx = ctx
+	p.now = time.Now()
+	p.totalPages = 0
+	p.sectionTotals = nil
+	p.usesTotalPages = false
+	p.palette = documentConfigure.Palette
+
+	if err := documentConfigure.ResolvePalette(); err != nil {
+		return &AttributeDecodeError{PageIndex: -1, Err: err}
+	}
+
+	// FONT
+	if err := p.loadFonts(documentConfigure); err != nil {
+		return err
+	}
+
+	// 総ページ数は描画し終えるまで確定しないため、テンプレートで参照されている場合はページ数を反映して描き直す
+	for pass := 0; pass < MaxLayoutPasses; pass++ {
+		if err := p.render(documentConfigure); err != nil {
+			return err
+		}
+		if !p.usesTotalPages || p.isPageCountFixed() {
+			return nil
+		}
+		p.totalPages = p.pageNumber
+		p.sectionTotals = append([]uint{}, p.sectionPages...)
+	}
+
+	return &PageCountError{Passes: MaxLayoutPasses}
+}
+
+func (p *PDF) render(documentConfigure types.DocumentConfigure) error {
 	p.gp = gopdf.GoPdf{}
+	p.data = documentConfigure.Data
+	p.colorSpace = documentConfigure.ColorSpace
+	p.item = nil
+	p.index = 0
+	p.sectionPages = make([]uint, len(documentConfigure.Pages))
+	p.pageNumber = 0
 
 	//fmt.Printf("%v\n", documentConfigure)
 
@@ -71,24 +132,17 @@ func (p *PDF) Draw(documentConfigure types.DocumentConfigure) {
 	)
 	p.gp.SetCompressLevel(documentConfigure.CompressLevel)
 
-	if err := p.gp.AddTTFFont("default", documentConfigure.TTFPath); err != nil {
-		log.Print(err.Error())
-		return
-	}
-	if err := p.gp.SetFont("default", "", documentConfigure.TextSize); err != nil {
-		log.Print(err.Error())
-		return
-	}
-
 	// FONT
-	var parser core.TTFParser
-	if err := parser.Parse(documentConfigure.TTFPath); err != nil {
-		log.Print(err.Error())
-		return
+	if err := p.addFonts(); err != nil {
+		return err
+	}
+	defaultFont, err := p.setFont(DefaultFontFamily, types.FontStyleRegular, documentConfigure.TextSize)
+	if err != nil {
+		return err
 	}
-	documentConfigure.SetFontHeight(float64(parser.Ascender()+parser.XHeight()+parser.Descender()) * 1000.00 / float64(parser.UnitsPerEm()))
+	documentConfigure.SetFontHeight(defaultFont.height)
 
-	p.gp.SetTextColor(documentConfigure.TextColor.R, documentConfigure.TextColor.G, documentConfigure.TextColor.B)
+	p.setTextColor(documentConfigure.TextColor)
 
 	// RECT
 	if !documentConfigure.CommonHeader.Size.IsZero() {
@@ -126,50 +180,35 @@ func (p *PDF) Draw(documentConfigure types.DocumentConfigure) {
 		},
 	}
 
-	// ELEMENT TEMPLATES
-	p.templates = map[string]interface{}{}
+	// ELEMENT TEMPLATES：要素ごとに属性を読み直す（色などのポインタを要素間で共有しないため）
+	p.templates = map[string]types.ElementTemplate{}
 
 	for _, elementTemplate := range documentConfigure.Templates {
 		//fmt.Printf("%v\n", elementTemplate.Id)
-		if elementTemplate.Type.IsText() {
-			var decoded = types.ElementText{
-				TextSize:        documentConfigure.TextSize,
-				Color:           types.Color{R: documentConfigure.TextColor.R, G: documentConfigure.TextColor.G, B: documentConfigure.TextColor.B},
-				BackgroundColor: types.Color{R: DefaultColorR, B: DefaultColorG, G: DefaultColorB},
-				Size:            types.Size{Width: UnsetWidth, Height: UnsetWidth},
-				Origin:          types.Origin{X: UnsetWidth, Y: UnsetHeight},
-				Border:          types.Border{Width: UnsetWidth, Color: types.Color{R: DefaultColorR, B: DefaultColorG, G: DefaultColorB}},
-				BorderTop:       types.Border{Width: UnsetWidth, Color: types.Color{R: DefaultColorR, B: DefaultColorG, G: DefaultColorB}},
-				BorderRight:     types.Border{Width: UnsetWidth, Color: types.Color{R: DefaultColorR, B: DefaultColorG, G: DefaultColorB}},
-				BorderBottom:    types.Border{Width: UnsetWidth, Color: types.Color{R: DefaultColorR, B: DefaultColorG, G: DefaultColorB}},
-				BorderLeft:      types.Border{Width: UnsetWidth, Color: types.Color{R: DefaultColorR, B: DefaultColorG, G: DefaultColorB}},
-			}
-			_ = json.Unmarshal(elementTemplate.Attributes, &decoded)
-			p.templates[elementTemplate.Id] = decoded
-		} else if elementTemplate.Type.IsImage() {
-			var decoded = types.ElementImage{
-				Size:       types.Size{Width: UnsetWidth, Height: UnsetWidth},
-				Origin:     types.Origin{X: UnsetWidth, Y: UnsetHeight},
-				Resize:     false,
-				Resolution: DefaultImageResolution,
-			}
-			_ = json.Unmarshal(elementTemplate.Attributes, &decoded)
-			p.templates[elementTemplate.Id] = decoded
-		}
+		p.templates[elementTemplate.Id] = elementTemplate
 	}
 	//fmt.Printf("templates: %v\n", p.templates)
 
 	// DRAW
-	for _, page := range documentConfigure.Pages {
+	for i, page := range documentConfigure.Pages {
+		if err := p.ctx.Err(); err != nil {
+			return err
+		}
+
 		p.gp.AddPage()
 		p.pageNumber += 1
+		p.pagePath = fmt.Sprintf("pages[%d]", i)
+		p.sectionIndex = i
+		p.sectionStart = p.pageNumber
 
-		// GLOBAL HEADER & FOOTER
-		if !p.commonHeaderRect.Size.IsZero() {
-			p.draw(documentConfigure, page, documentConfigure.CommonHeader.LinerLayout, p.commonHeaderRect, true, false)
+		// WATERMARK
+		if err := p.drawWatermark(documentConfigure, false); err != nil {
+			return err
 		}
-		if !p.commonFooterRect.Size.IsZero() {
-			p.draw(documentConfigure, page, documentConfigure.CommonFooter.LinerLayout, p.commonFooterRect, true, true)
+
+		// GLOBAL HEADER & FOOTER
+		if err := p.drawCommonHeaderFooter(documentConfigure, page); err != nil {
+			return err
 		}
 
 		pageHeaderRect := types.Rect{}
@@ -188,7 +227,9 @@ func (p *PDF) Draw(documentConfigure types.DocumentConfigure) {
 			contentRect = contentRect.ApplyMargin(types.Margin{
 				Top: page.PageHeader.Size.Height,
 			})
-			p.draw(documentConfigure, page, page.PageHeader.LinerLayout, pageHeaderRect, true, false)
+			if _, err := p.drawLinerLayout(documentConfigure, page, page.PageHeader.LinerLayout, pageHeaderRect, true, false, p.pagePath+".page_header.liner_layout", types.OrientationVertical); err != nil {
+				return err
+			}
 		}
 
 		// DRAW FIXED TITLE
@@ -203,11 +244,17 @@ func (p *PDF) Draw(documentConfigure types.DocumentConfigure) {
 			contentRect = contentRect.ApplyMargin(types.Margin{
 				Top: page.FixedTitle.Size.Height,
 			})
-			p.draw(documentConfigure, page, page.FixedTitle.LinerLayout, titleRect, true, false)
+			if _, err := p.drawLinerLayout(documentConfigure, page, page.FixedTitle.LinerLayout, titleRect, true, false, p.pagePath+".fixed_title.liner_layout", types.OrientationVertical); err != nil {
+				return err
+			}
 		}
 
 		// DRAW PAGE CONTENT
-		wrapRect := p.draw(documentConfigure, page, page.LinerLayout, contentRect, true, false)
+		p.pageTop = contentRect.MinY()
+		wrapRect, err := p.drawLinerLayout(documentConfigure, page, page.LinerLayout, contentRect, true, false, p.pagePath+".liner_layout", types.OrientationVertical)
+		if err != nil {
+			return err
+		}
 		//fmt.Printf("rect: %v\n", rect)
 
 		// DRAW PAGE FOOTER
@@ -222,21 +269,49 @@ func (p *PDF) Draw(documentConfigure types.DocumentConfigure) {
 			}
 		}
 		if !pageFooterRect.Size.IsZero() {
-			p.draw(documentConfigure, page, page.PageFooter.LinerLayout, pageFooterRect, true, true)
+			if _, err := p.drawLinerLayout(documentConfigure, page, page.PageFooter.LinerLayout, pageFooterRect, true, true, p.pagePath+".page_footer.liner_layout", types.OrientationVertical); err != nil {
+				return err
+			}
+		}
+
+		// WATERMARK
+		if err := p.drawWatermark(documentConfigure, true); err != nil {
+			return err
+		}
+
+		p.sectionPages[i] = p.pageNumber - p.sectionStart + 1
+	}
+
+	return nil
+}
+
+// 判定：前回の描画とページ数が一致
+func (p *PDF) isPageCountFixed() bool {
+	if p.totalPages != p.pageNumber || len(p.sectionTotals) != len(p.sectionPages) {
+		return false
+	}
+	for i := range p.sectionPages {
+		if p.sectionTotals[i] != p.sectionPages[i] {
+			return false
 		}
 	}
+	return true
 }
 
 func (p *PDF) Save(outputPath string) error {
 	return p.gp.WritePdf(outputPath)
 }
 
+func (p *PDF) Write(w io.Writer) error {
+	return p.gp.Write(w)
+}
+
 func (p *PDF) Destroy() {
 	_ = p.gp.Close()
 }
 
 // 描画要素のループ
-func (p *PDF) draw(documentConfigure types.DocumentConfigure, page types.Page, linerLayout types.LinerLayout, parentRect types.Rect, needMoveAxis bool, isFooter bool) types.Rect {
+func (p *PDF) draw(documentConfigure types.DocumentConfigure, page types.Page, linerLayout types.LinerLayout, parentRect types.Rect, needMoveAxis bool, isFooter bool, path string) (types.Rect, error) {
 	if needMoveAxis {
 		p.gp.SetX(parentRect.MinX())
 		p.gp.SetY(parentRect.MinY())
@@ -249,61 +324,64 @@ func (p *PDF) draw(documentConfigure types.DocumentConfigure, page types.Page, l
 
 	if len(linerLayout.Elements) > 0 {
 
-		for _, element := range linerLayout.Elements {
+		for i, element := range linerLayout.Elements {
+			if err := p.ctx.Err(); err != nil {
+				return wrapRect, err
+			}
+
+			elementPath := fmt.Sprintf("%s.elements[%d]", path, i)
+
+			// VISIBLE
+			if visible, err := p.isVisible(element.VisibleIf, elementPath+".visible_if"); err != nil {
+				return wrapRect, err
+			} else if !visible {
+				continue
+			}
+
 			if element.Type.IsLineBreak() {
 				var decoded = types.ElementLineBreak{
 					Height: UnsetHeight,
 				}
-				_ = json.Unmarshal(element.Attributes, &decoded)
+				if err := p.decodeAttributes(element.Attributes, &decoded, elementPath); err != nil {
+					return wrapRect, err
+				}
 				p.breakLine(&lineWrapRect, decoded.Height)
 
 			} else if element.Type.IsText() {
-				var decoded types.ElementText
-				if element.TemplateId != "" {
-					templateText, ok := p.templates[element.TemplateId].(types.ElementText)
-					if ok {
-						decoded = templateText
+				var decoded = newElementText(documentConfigure)
+				if elementTemplate, ok := p.templates[element.TemplateId]; ok && elementTemplate.Type.IsText() {
+					if err := p.decodeAttributes(elementTemplate.Attributes, &decoded, elementPath+".template_id"); err != nil {
+						return wrapRect, err
 					}
 				}
-				if decoded.TextSize == 0 {
-					decoded = types.ElementText{
-						TextSize:        documentConfigure.TextSize,
-						Color:           types.Color{R: documentConfigure.TextColor.R, G: documentConfigure.TextColor.G, B: documentConfigure.TextColor.B},
-						BackgroundColor: types.Color{R: DefaultColorR, B: DefaultColorG, G: DefaultColorB},
-						Size:            types.Size{Width: UnsetWidth, Height: UnsetWidth},
-						Origin:          types.Origin{X: UnsetWidth, Y: UnsetHeight},
-						Border:          types.Border{Width: UnsetWidth, Color: types.Color{R: DefaultColorR, B: DefaultColorG, G: DefaultColorB}},
-						BorderTop:       types.Border{Width: UnsetWidth, Color: types.Color{R: DefaultColorR, B: DefaultColorG, G: DefaultColorB}},
-						BorderRight:     types.Border{Width: UnsetWidth, Color: types.Color{R: DefaultColorR, B: DefaultColorG, G: DefaultColorB}},
-						BorderBottom:    types.Border{Width: UnsetWidth, Color: types.Color{R: DefaultColorR, B: DefaultColorG, G: DefaultColorB}},
-						BorderLeft:      types.Border{Width: UnsetWidth, Color: types.Color{R: DefaultColorR, B: DefaultColorG, G: DefaultColorB}},
-					}
+				if err := p.decodeAttributes(element.Attributes, &decoded, elementPath); err != nil {
+					return wrapRect, err
+				}
+				if err := p.resolvePalette(&decoded, elementPath); err != nil {
+					return wrapRect, err
 				}
-				_ = json.Unmarshal(element.Attributes, &decoded)
 
 				//fmt.Printf("---------------------------\n%v\n", decoded.Text)
 
 				// BUILD TEXT
-				vars := struct {
-					PageNumber uint
-					Now        string
-				}{
-					p.pageNumber,
-					time.Now().Format("2006-01-02 15:04:05"),
-				}
-				tmpl, err := template.New("text").Parse(decoded.Text)
+				text, err := p.executeTemplate(decoded.Text, elementPath)
 				if err != nil {
-					panic(err)
+					return wrapRect, err
 				}
-				var buf bytes.Buffer
-				err = tmpl.Execute(&buf, vars)
-				if err != nil {
-					panic(err)
+				decoded.Text = text
+				for i := range decoded.Spans {
+					text, err := p.executeTemplate(decoded.Spans[i].Text, fmt.Sprintf("%s.spans[%d]", elementPath, i))
+					if err != nil {
+						return wrapRect, err
+					}
+					decoded.Spans[i].Text = text
 				}
-				decoded.Text = buf.String()
 
 				// ACTUAL SIZE
-				measureSize := p.measureText(documentConfigure, decoded)
+				measureSize, err := p.measureText(documentConfigure, decoded)
+				if err != nil {
+					return wrapRect, p.fontError(err, elementPath)
+				}
 
 				// LAYOUT SIZE
 				if decoded.Layout.Width.IsMatchParent() || decoded.Layout.Height.IsMatchParent() {
@@ -316,23 +394,39 @@ func (p *PDF) draw(documentConfigure types.DocumentConfigure, page types.Page, l
 						measureSize.Height = elementLayoutSize.Height
 					}
 
-					if decoded.Wrap && decoded.Size.IsZero() {
-						texts, _ := p.gp.SplitText(decoded.Text, elementLayoutSize.Width)
-						measureSize.Height = measureSize.Height * float64(len(texts))
+					if decoded.Wrap && decoded.Size.IsZero() && decoded.WritingMode.IsHorizontal() {
+						lines, err := p.wrapText(p.textSpans(decoded), elementLayoutSize.Width)
+						if err != nil {
+							return wrapRect, p.fontError(err, elementPath)
+						}
+						measureSize.Height = 0
+						for i, line := range lines {
+							if i > 0 && lines[i-1].end {
+								measureSize.Height += decoded.ParagraphSpacing
+							}
+							measureSize.Height += decoded.LineHeight.Apply(line.height())
+						}
 					}
 				}
 
 				// TOTAL SIZE
-				size := types.Size{Width: measureSize.Width + decoded.Margin.Horizontal(), Height: measureSize.Height + decoded.Margin.Vertical()}
+				frameSize := types.Size{Width: measureSize.Width + decoded.Margin.Horizontal(), Height: measureSize.Height + decoded.Margin.Vertical()}
+
+				// ROTATION
+				size := rotatedSize(frameSize, decoded.Rotation)
 
 				// DRAW FIXED POSITION
 				if decoded.Origin.X != UnsetX && decoded.Origin.Y != UnsetY {
-					textFrame := types.Rect{Origin: types.Origin{X: decoded.Origin.X, Y: decoded.Origin.Y}, Size: size}
+					textFrame := types.Rect{Origin: types.Origin{X: decoded.Origin.X, Y: decoded.Origin.Y}, Size: frameSize}
 					textRect := textFrame.ApplyMargin(decoded.Margin)
 					textRect = textRect.ApplyContentMargin(decoded.ContentMargin)
 					p.gp.SetX(textRect.MinX())
 					p.gp.SetY(textRect.MinY())
-					p.drawText(documentConfigure, decoded, textRect, textFrame)
+					if err := p.drawRotated(decoded.Rotation, decoded.RotationAnchor.Point(textFrame), func() error {
+						return p.drawText(documentConfigure, decoded, textRect, textFrame)
+					}); err != nil {
+						return wrapRect, p.fontError(err, elementPath)
+					}
 					continue
 				}
 
@@ -350,41 +444,20 @@ func (p *PDF) draw(documentConfigure types.DocumentConfigure, page types.Page, l
 				// PAGE BREAK
 				if p.needPageBreak(lineWrapRect, size) && !isFooter {
 					//fmt.Print("> page break\n")
-					p.gp.AddPage()
-					p.pageNumber += 1
-					p.breakPage(&lineWrapRect, &wrapRect)
-
-					if !p.commonHeaderRect.Size.IsZero() {
-						p.draw(documentConfigure, page, documentConfigure.CommonHeader.LinerLayout, p.commonHeaderRect, true, false)
-					}
-					if !p.commonFooterRect.Size.IsZero() {
-						p.draw(documentConfigure, page, documentConfigure.CommonFooter.LinerLayout, p.commonFooterRect, true, true)
-					}
-
-					// DRAW FIXED TITLE
-					if !page.FixedTitle.Size.IsZero() {
-						titleRect := types.Rect{
-							Origin: types.Origin{X: wrapRect.MinX(), Y: wrapRect.MinY()},
-							Size:   page.FixedTitle.Size,
-						}
-						if titleRect.Size.Width == UnsetWidth {
-							titleRect.Size.Width = p.contentRect.Width()
+					// ページの先頭から置いている場合は改ページしても収まらないので、白紙のページを追加しない
+					if !p.isPageTop(lineWrapRect.MinY()) {
+						if err := p.addPage(documentConfigure, page, &lineWrapRect, &wrapRect); err != nil {
+							return wrapRect, err
 						}
-						p.draw(documentConfigure, page, page.FixedTitle.LinerLayout, titleRect, true, false)
-						lineWrapRect = lineWrapRect.ApplyMargin(types.Margin{
-							Top: titleRect.Size.Height,
-						})
-						wrapRect = wrapRect.ApplyMargin(types.Margin{
-							Top: titleRect.Size.Height,
-						})
 					}
-
-					p.gp.SetX(wrapRect.MinX())
-					p.gp.SetY(wrapRect.MinY())
+					if p.needPageBreak(lineWrapRect, size) {
+						return wrapRect, p.overflowError(elementPath, size, lineWrapRect)
+					}
 				}
 
 				// DRAWABLE RECT
-				textFrame := types.Rect{Origin: types.Origin{X: lineWrapRect.MaxX(), Y: lineWrapRect.MinY()}, Size: size}
+				boundingRect := types.Rect{Origin: types.Origin{X: lineWrapRect.MaxX(), Y: lineWrapRect.MinY()}, Size: size}
+				textFrame := centerRect(boundingRect, frameSize)
 				textRect := textFrame.ApplyMargin(decoded.Margin)
 				textRect = textRect.ApplyContentMargin(decoded.ContentMargin)
 				p.gp.SetX(textRect.MinX())
@@ -393,32 +466,36 @@ func (p *PDF) draw(documentConfigure types.DocumentConfigure, page types.Page, l
 				// DRAW
 				//fmt.Printf("textRect: %v\n", textRect)
 				//fmt.Printf("lineWrapRect: %v\n", lineWrapRect)
-				p.drawText(documentConfigure, decoded, textRect, textFrame)
+				// 外接矩形の中央に置くため、rotation_anchor によらず中心で回転する
+				if err := p.drawRotated(decoded.Rotation, types.Anchor(types.AnchorCenter).Point(textFrame), func() error {
+					return p.drawText(documentConfigure, decoded, textRect, textFrame)
+				}); err != nil {
+					return wrapRect, p.fontError(err, elementPath)
+				}
 
-				lineWrapRect = lineWrapRect.Merge(textFrame)
+				lineWrapRect = lineWrapRect.Merge(boundingRect)
 
 			} else if element.Type.IsImage() {
-				var decoded types.ElementImage
-				if element.TemplateId != "" {
-					templateImage, ok := p.templates[element.TemplateId].(types.ElementImage)
-					if ok {
-						decoded = templateImage
+				var decoded = newElementImage()
+				if elementTemplate, ok := p.templates[element.TemplateId]; ok && elementTemplate.Type.IsImage() {
+					if err := p.decodeAttributes(elementTemplate.Attributes, &decoded, elementPath+".template_id"); err != nil {
+						return wrapRect, err
 					}
 				}
-				if decoded.Resolution == 0 {
-					decoded = types.ElementImage{
-						Size:       types.Size{Width: UnsetWidth, Height: UnsetWidth},
-						Origin:     types.Origin{X: UnsetWidth, Y: UnsetHeight},
-						Resize:     false,
-						Resolution: DefaultImageResolution,
-					}
+				if err := p.decodeAttributes(element.Attributes, &decoded, elementPath); err != nil {
+					return wrapRect, err
+				}
+				if err := p.resolvePalette(&decoded, elementPath); err != nil {
+					return wrapRect, err
 				}
-				_ = json.Unmarshal(element.Attributes, &decoded)
 
 				//fmt.Printf("---------------------------\n%v\n", decoded.Path)
 
 				// Actual Size
-				measureSize := p.measureImage(documentConfigure, decoded)
+				measureSize, err := p.measureImage(documentConfigure, decoded)
+				if err != nil {
+					return wrapRect, p.imageError(err, elementPath)
+				}
 
 				// Layout Size
 				if decoded.Layout.Width.IsMatchParent() || decoded.Layout.Height.IsMatchParent() {
@@ -434,16 +511,23 @@ func (p *PDF) draw(documentConfigure types.DocumentConfigure, page types.Page, l
 				}
 
 				// TOTAL SIZE
-				size := types.Size{Width: measureSize.Width, Height: measureSize.Height}
+				frameSize := types.Size{Width: measureSize.Width, Height: measureSize.Height}
+
+				// ROTATION
+				size := rotatedSize(frameSize, decoded.Rotation)
 
 				// DRAW FIXED POSITION
 				if decoded.Origin.X != UnsetX && decoded.Origin.Y != UnsetY {
-					imageFrame := types.Rect{Origin: types.Origin{X: decoded.Origin.X, Y: decoded.Origin.Y}, Size: size}
+					imageFrame := types.Rect{Origin: types.Origin{X: decoded.Origin.X, Y: decoded.Origin.Y}, Size: frameSize}
 					imageRect := imageFrame.ApplyMargin(decoded.Margin)
 					imageRect = imageRect.ApplyContentMargin(decoded.ContentMargin)
 					p.gp.SetX(imageRect.MinX())
 					p.gp.SetY(imageRect.MinY())
-					p.drawImage(documentConfigure, decoded, imageRect, imageFrame)
+					if err := p.drawRotated(decoded.Rotation, decoded.RotationAnchor.Point(imageFrame), func() error {
+						return p.drawImage(documentConfigure, decoded, imageRect, imageFrame)
+					}); err != nil {
+						return wrapRect, p.imageError(err, elementPath)
+					}
 					continue
 				}
 
@@ -461,41 +545,20 @@ func (p *PDF) draw(documentConfigure types.DocumentConfigure, page types.Page, l
 				// PAGE BREAK
 				if p.needPageBreak(lineWrapRect, size) {
 					//fmt.Print("> page break\n")
-					p.gp.AddPage()
-					p.pageNumber += 1
-					p.breakPage(&lineWrapRect, &wrapRect)
-
-					if !p.commonHeaderRect.Size.IsZero() {
-						p.draw(documentConfigure, page, documentConfigure.CommonHeader.LinerLayout, p.commonHeaderRect, true, false)
-					}
-					if !p.commonFooterRect.Size.IsZero() {
-						p.draw(documentConfigure, page, documentConfigure.CommonFooter.LinerLayout, p.commonFooterRect, true, true)
-					}
-
-					// DRAW FIXED TITLE
-					if !page.FixedTitle.Size.IsZero() {
-						titleRect := types.Rect{
-							Origin: types.Origin{X: wrapRect.MinX(), Y: wrapRect.MinY()},
-							Size:   page.FixedTitle.Size,
-						}
-						if titleRect.Size.Width == UnsetWidth {
-							titleRect.Size.Width = p.contentRect.Width()
+					// ページの先頭から置いている場合は改ページしても収まらないので、白紙のページを追加しない
+					if !p.isPageTop(lineWrapRect.MinY()) {
+						if err := p.addPage(documentConfigure, page, &lineWrapRect, &wrapRect); err != nil {
+							return wrapRect, err
 						}
-						p.draw(documentConfigure, page, page.FixedTitle.LinerLayout, titleRect, true, false)
-						lineWrapRect = lineWrapRect.ApplyMargin(types.Margin{
-							Top: titleRect.Size.Height,
-						})
-						wrapRect = wrapRect.ApplyMargin(types.Margin{
-							Top: titleRect.Size.Height,
-						})
 					}
-
-					p.gp.SetX(wrapRect.MinX())
-					p.gp.SetY(wrapRect.MinY())
+					if p.needPageBreak(lineWrapRect, size) {
+						return wrapRect, p.overflowError(elementPath, size, lineWrapRect)
+					}
 				}
 
 				// DRAWABLE RECT
-				imageFrame := types.Rect{Origin: types.Origin{X: lineWrapRect.MaxX(), Y: lineWrapRect.MinY()}, Size: size}
+				boundingRect := types.Rect{Origin: types.Origin{X: lineWrapRect.MaxX(), Y: lineWrapRect.MinY()}, Size: size}
+				imageFrame := centerRect(boundingRect, frameSize)
 				imageRect := imageFrame.ApplyMargin(decoded.Margin)
 				imageRect = imageRect.ApplyContentMargin(decoded.ContentMargin)
 				p.gp.SetX(imageRect.MinX())
@@ -508,9 +571,75 @@ func (p *PDF) draw(documentConfigure types.DocumentConfigure, page types.Page, l
 				//p.gp.SetStrokeColor(255, 255, 0)
 				//p.gp.RectFromUpperLeft(imageRect.Origin.X, imageRect.Origin.Y, imageRect.Size.Width, imageRect.Size.Height)
 				// < debug
-				p.drawImage(documentConfigure, decoded, imageRect, imageFrame)
+				// 外接矩形の中央に置くため、rotation_anchor によらず中心で回転する
+				if err := p.drawRotated(decoded.Rotation, types.Anchor(types.AnchorCenter).Point(imageFrame), func() error {
+					return p.drawImage(documentConfigure, decoded, imageRect, imageFrame)
+				}); err != nil {
+					return wrapRect, p.imageError(err, elementPath)
+				}
+
+				lineWrapRect = lineWrapRect.Merge(boundingRect)
+
+			} else if element.Type.IsTable() {
+				var decoded = types.ElementTable{
+					TextSize: documentConfigure.TextSize,
+					Color:    documentConfigure.TextColor,
+					Size:     types.Size{Width: UnsetWidth, Height: UnsetHeight},
+					Origin:   types.Origin{X: UnsetX, Y: UnsetY},
+				}
+				if err := p.decodeAttributes(element.Attributes, &decoded, elementPath); err != nil {
+					return wrapRect, err
+				}
+				if err := p.resolvePalette(&decoded, elementPath); err != nil {
+					return wrapRect, err
+				}
+
+				// TABLE WIDTH
+				width := decoded.Size.Width
+				if width == UnsetWidth {
+					elementLayoutSize := p.calcLayoutSize(parentLayoutSize, decoded.Layout)
+					if elementLayoutSize.Width != UnsetWidth {
+						width = elementLayoutSize.Width - decoded.Margin.Horizontal()
+					} else {
+						width = p.contentRect.MaxX() - lineWrapRect.MinX() - decoded.Margin.Horizontal()
+					}
+				}
+
+				// ACTUAL SIZE
+				layout, err := p.layoutTable(documentConfigure, decoded, width, elementPath)
+				if err != nil {
+					return wrapRect, err
+				}
 
-				lineWrapRect = lineWrapRect.Merge(imageFrame)
+				// TOTAL SIZE
+				size := types.Size{Width: layout.width() + decoded.Margin.Horizontal(), Height: layout.height(0, len(decoded.Rows)) + decoded.Margin.Vertical()}
+
+				// DRAW FIXED POSITION
+				if decoded.Origin.X != UnsetX && decoded.Origin.Y != UnsetY {
+					fixedRect := types.Rect{Origin: types.Origin{X: decoded.Origin.X, Y: decoded.Origin.Y}}
+					if _, err := p.drawTable(documentConfigure, page, decoded, layout, &fixedRect, &fixedRect, false, elementPath); err != nil {
+						return wrapRect, err
+					}
+					continue
+				}
+
+				// VERTICAL
+				if linerLayout.Orientation.IsVertical() {
+					p.breakLine(&lineWrapRect, linerLayout.LineHeight)
+				}
+
+				// LINE BREAK
+				if p.needLineBreak(lineWrapRect, size) {
+					p.breakLine(&lineWrapRect, linerLayout.LineHeight)
+				}
+
+				// DRAW (PAGE BREAK)
+				tableFrame, err := p.drawTable(documentConfigure, page, decoded, layout, &lineWrapRect, &wrapRect, !isFooter, elementPath)
+				if err != nil {
+					return wrapRect, err
+				}
+
+				lineWrapRect = lineWrapRect.Merge(tableFrame)
 			}
 
 			wrapRect = wrapRect.Merge(lineWrapRect)
@@ -521,11 +650,14 @@ func (p *PDF) draw(documentConfigure types.DocumentConfigure, page types.Page, l
 		//p.gp.RectFromUpperLeft(wrapRect.Origin.X, wrapRect.Origin.Y, wrapRect.Width(), wrapRect.Height())
 		// < debug
 
-		return wrapRect
+		return wrapRect, nil
 	}
 
-	for _, _linerLayout := range linerLayout.LinerLayouts {
-		drawnRect := p.draw(documentConfigure, page, _linerLayout, parentRect, false, false)
+	for i, _linerLayout := range linerLayout.LinerLayouts {
+		drawnRect, err := p.drawLinerLayout(documentConfigure, page, _linerLayout, parentRect, false, false, fmt.Sprintf("%s.liner_layouts[%d]", path, i), linerLayout.Orientation)
+		if err != nil {
+			return wrapRect, err
+		}
 		wrapRect = wrapRect.Merge(drawnRect)
 
 		// > debug
@@ -533,52 +665,195 @@ func (p *PDF) draw(documentConfigure types.DocumentConfigure, page types.Page, l
 		//p.gp.RectFromUpperLeft(wrapRect.Origin.X, wrapRect.Origin.Y, wrapRect.Size.Width, wrapRect.Size.Height)
 		// < debug
 
-		if linerLayout.Orientation.IsHorizontal() {
-			p.gp.SetX(drawnRect.MaxX())
-			p.gp.SetY(drawnRect.MinY())
-		} else if linerLayout.Orientation.IsVertical() {
-			p.gp.SetX(drawnRect.MinX())
-			p.gp.SetY(drawnRect.MaxY())
+		p.moveAxis(drawnRect, linerLayout.Orientation)
+	}
+
+	return wrapRect, nil
+}
+
+// 描画：レイアウト（repeat 指定時はデータの件数分を親の向きに並べる）
+func (p *PDF) drawLinerLayout(documentConfigure types.DocumentConfigure, page types.Page, linerLayout types.LinerLayout, parentRect types.Rect, needMoveAxis bool, isFooter bool, path string, orientation types.Orientation) (types.Rect, error) {
+	var emptyRect = types.Rect{Origin: types.Origin{X: p.gp.GetX(), Y: p.gp.GetY()}}
+	if needMoveAxis {
+		emptyRect.Origin = parentRect.Origin
+	}
+
+	if linerLayout.Repeat == "" {
+		if visible, err := p.isVisible(linerLayout.VisibleIf, path+".visible_if"); err != nil || !visible {
+			return emptyRect, err
+		}
+		return p.draw(documentConfigure, page, linerLayout, parentRect, needMoveAxis, isFooter, path)
+	}
+
+	items, err := p.lookupItems(linerLayout.Repeat)
+	if err != nil {
+		return types.Rect{}, &TemplateParseError{PageIndex: p.pageIndex(), Path: path + ".repeat", Text: linerLayout.Repeat, Err: err}
+	}
+
+	var item, index = p.item, p.index
+	defer func() {
+		p.item, p.index = item, index
+	}()
+
+	var instance = linerLayout
+	instance.Repeat = ""
+
+	var wrapRect = emptyRect
+	var drawn = false
+	for i := range items {
+		p.item, p.index = items[i], i
+
+		// 表示条件は要素ごとに判定する
+		if visible, err := p.isVisible(linerLayout.VisibleIf, path+".visible_if"); err != nil {
+			return wrapRect, err
+		} else if !visible {
+			continue
+		}
+
+		drawnRect, err := p.draw(documentConfigure, page, instance, parentRect, needMoveAxis && !drawn, isFooter, path)
+		if err != nil {
+			return wrapRect, err
+		}
+		if !drawn {
+			wrapRect = drawnRect
+			drawn = true
+		} else {
+			wrapRect = wrapRect.Merge(drawnRect)
 		}
+
+		p.moveAxis(drawnRect, orientation)
+	}
+
+	return wrapRect, nil
+}
+
+// 次のレイアウトの描画位置へ移動
+func (p *PDF) moveAxis(drawnRect types.Rect, orientation types.Orientation) {
+	if orientation.IsHorizontal() {
+		p.gp.SetX(drawnRect.MaxX())
+		p.gp.SetY(drawnRect.MinY())
+	} else if orientation.IsVertical() {
+		p.gp.SetX(drawnRect.MinX())
+		p.gp.SetY(drawnRect.MaxY())
 	}
+}
 
-	return wrapRect
+// 描画：共通ヘッダー・フッター
+func (p *PDF) drawCommonHeaderFooter(documentConfigure types.DocumentConfigure, page types.Page) error {
+	if !p.commonHeaderRect.Size.IsZero() {
+		if _, err := p.drawLinerLayout(documentConfigure, page, documentConfigure.CommonHeader.LinerLayout, p.commonHeaderRect, true, false, "header.liner_layout", types.OrientationVertical); err != nil {
+			return err
+		}
+	}
+	if !p.commonFooterRect.Size.IsZero() {
+		if _, err := p.drawLinerLayout(documentConfigure, page, documentConfigure.CommonFooter.LinerLayout, p.commonFooterRect, true, true, "footer.liner_layout", types.OrientationVertical); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 改ページして透かし、共通ヘッダー・フッター、固定タイトルを描画
+func (p *PDF) addPage(documentConfigure types.DocumentConfigure, page types.Page, lineWrapRect *types.Rect, wrapRect *types.Rect) error {
+	// 透かし・共通ヘッダー・フッター、固定タイトルは繰り返し中の要素を参照しない
+	var item, index = p.item, p.index
+	p.item, p.index = nil, 0
+	defer func() {
+		p.item, p.index = item, index
+	}()
+
+	// WATERMARK：前のページの前面
+	if err := p.drawWatermark(documentConfigure, true); err != nil {
+		return err
+	}
+
+	p.gp.AddPage()
+	p.pageNumber += 1
+	p.breakPage(lineWrapRect, wrapRect)
+
+	// WATERMARK：背面
+	if err := p.drawWatermark(documentConfigure, false); err != nil {
+		return err
+	}
+
+	if err := p.drawCommonHeaderFooter(documentConfigure, page); err != nil {
+		return err
+	}
+
+	// DRAW FIXED TITLE
+	if !page.FixedTitle.Size.IsZero() {
+		titleRect := types.Rect{
+			Origin: types.Origin{X: wrapRect.MinX(), Y: wrapRect.MinY()},
+			Size:   page.FixedTitle.Size,
+		}
+		if titleRect.Size.Width == UnsetWidth {
+			titleRect.Size.Width = p.contentRect.Width()
+		}
+		if _, err := p.drawLinerLayout(documentConfigure, page, page.FixedTitle.LinerLayout, titleRect, true, false, p.pagePath+".fixed_title.liner_layout", types.OrientationVertical); err != nil {
+			return err
+		}
+		*lineWrapRect = lineWrapRect.ApplyMargin(types.Margin{
+			Top: titleRect.Size.Height,
+		})
+		*wrapRect = wrapRect.ApplyMargin(types.Margin{
+			Top: titleRect.Size.Height,
+		})
+	}
+
+	p.pageTop = wrapRect.MinY()
+	p.gp.SetX(wrapRect.MinX())
+	p.gp.SetY(wrapRect.MinY())
+
+	return nil
 }
 
 // 計算：テキストのサイズ
-func (p *PDF) measureText(documentConfigure types.DocumentConfigure, decoded types.ElementText) types.Size {
-	if err := p.gp.SetFont("default", "", decoded.TextSize); err != nil {
-		log.Print(err.Error())
+func (p *PDF) measureText(documentConfigure types.DocumentConfigure, decoded types.ElementText) (types.Size, error) {
+	if decoded.WritingMode.IsVertical() {
+		return p.measureVerticalText(decoded)
+	}
+
+	if _, err := p.setFont(decoded.FontFamily, decoded.FontStyle, decoded.TextSize); err != nil {
+		return types.Size{}, err
 	}
 
-	if p.isMultiLineText(decoded.Text) {
+	paragraphs := splitParagraphs(p.textSpans(decoded))
+	if len(paragraphs) > 1 {
 		measureSize := types.Size{}
-		measureHeight := documentConfigure.FontHeight() * (float64(decoded.TextSize) / 1000.0)
 
-		texts := strings.Split(decoded.Text, "\n")
-		for _, text := range texts {
-			measureWidth, _ := p.gp.MeasureTextWidth(text)
-			if measureSize.Width < measureWidth {
-				measureSize.Width = measureWidth
+		for i, paragraph := range paragraphs {
+			line, err := p.layoutLine(paragraph)
+			if err != nil {
+				return types.Size{}, err
+			}
+			if measureSize.Width < line.width {
+				measureSize.Width = line.width
 			}
-			measureSize.Height += measureHeight
+			if i > 0 {
+				measureSize.Height += decoded.ParagraphSpacing
+			}
+			measureSize.Height += decoded.LineHeight.Apply(line.height())
 		}
 
-		if decoded.Size.Width != UnsetWidth && decoded.Size.Height == UnsetHeight {
+		if decoded.Size.Width != UnsetWidth {
 			measureSize.Width = decoded.Size.Width
 		}
-		if decoded.Size.Width == UnsetWidth && decoded.Size.Height != UnsetHeight {
+		if decoded.Size.Height != UnsetHeight {
 			measureSize.Height = decoded.Size.Height
 		}
 
 		//measureSize.Width += decoded.Margin.Horizontal()
 		//measureSize.Height += decoded.Margin.Vertical()
 
-		return measureSize
+		return measureSize, nil
 	}
 
-	measureWidth, _ := p.gp.MeasureTextWidth(decoded.Text)
-	measureHeight := documentConfigure.FontHeight() * (float64(decoded.TextSize) / 1000.0)
+	line, err := p.layoutLine(paragraphs[0])
+	if err != nil {
+		return types.Size{}, err
+	}
+	measureWidth := line.width
+	measureHeight := decoded.LineHeight.Apply(line.height())
 
 	var measureSize types.Size
 	if decoded.Size.Width != UnsetWidth && decoded.Size.Height != UnsetHeight {
@@ -594,14 +869,20 @@ func (p *PDF) measureText(documentConfigure types.DocumentConfigure, decoded typ
 	//measureSize.Width += decoded.Margin.Horizontal()
 	//measureSize.Height += decoded.Margin.Vertical()
 
-	return measureSize
+	return measureSize, nil
 }
 
 // 計算：画像のサイズ
-func (p *PDF) measureImage(documentConfigure types.DocumentConfigure, decoded types.ElementImage) types.Size {
-	file, _ := os.Open(decoded.Path)
-	imgConfig, _, _ := image.DecodeConfig(file)
+func (p *PDF) measureImage(documentConfigure types.DocumentConfigure, decoded types.ElementImage) (types.Size, error) {
+	file, err := os.Open(decoded.Path)
+	if err != nil {
+		return types.Size{}, p.imageDecodeError(err, decoded.Path)
+	}
+	imgConfig, _, err := image.DecodeConfig(file)
 	_ = file.Close()
+	if err != nil {
+		return types.Size{}, p.imageDecodeError(err, decoded.Path)
+	}
 
 	var measureSize types.Size
 	if decoded.Size.Width != UnsetWidth && decoded.Size.Height != UnsetHeight && decoded.Size.Width < float64(imgConfig.Width) && decoded.Size.Height < float64(imgConfig.Height) {
@@ -626,7 +907,7 @@ func (p *PDF) measureImage(documentConfigure types.DocumentConfigure, decoded ty
 	//measureSize.Width += decoded.Margin.Horizontal()
 	//measureSize.Height += decoded.Margin.Vertical()
 
-	return measureSize
+	return measureSize, nil
 }
 
 // 計算：レイアウトサイズ
@@ -641,136 +922,232 @@ func (p *PDF) calcLayoutSize(size types.Size, layout types.Layout) types.Size {
 	return layoutSize
 }
 
-// 描画：テキスト
-func (p *PDF) drawText(documentConfigure types.DocumentConfigure, decoded types.ElementText, textRect types.Rect, textFrame types.Rect) {
-	// BORDER, FILL
-	if decoded.Border.Width != UnsetWidth {
-		p.gp.SetLineWidth(decoded.Border.Width)
-		p.gp.SetStrokeColor(decoded.Border.Color.R, decoded.Border.Color.G, decoded.Border.Color.B)
-		if decoded.BackgroundColor.R != DefaultColorR || decoded.BackgroundColor.G != DefaultColorG || decoded.BackgroundColor.B != DefaultColorB {
-			p.gp.SetFillColor(decoded.BackgroundColor.R, decoded.BackgroundColor.G, decoded.BackgroundColor.B)
-			p.gp.RectFromUpperLeftWithStyle(textFrame.MinX(), textFrame.MinY(), textFrame.Width(), textFrame.Height(), "FD")
-		} else {
-			p.gp.RectFromUpperLeft(textFrame.MinX(), textFrame.MinY(), textFrame.Width(), textFrame.Height())
-		}
-	} else {
-		if decoded.BorderTop.Width != UnsetWidth {
-			p.gp.SetLineWidth(decoded.BorderTop.Width)
-			p.gp.SetStrokeColor(decoded.BorderTop.Color.R, decoded.BorderTop.Color.G, decoded.BorderTop.Color.B)
-			p.gp.Line(textFrame.MinX(), textFrame.MinY(), textFrame.MinX()+textFrame.Width(), textFrame.MinY())
-		}
-		if decoded.BorderRight.Width != UnsetWidth {
-			p.gp.SetLineWidth(decoded.BorderRight.Width)
-			p.gp.SetStrokeColor(decoded.BorderRight.Color.R, decoded.BorderRight.Color.G, decoded.BorderRight.Color.B)
-			p.gp.Line(textFrame.MinX()+textFrame.Width(), textFrame.MinY(), textFrame.MinX()+textFrame.Width(), textFrame.MinY()+textFrame.Height())
-		}
-		if decoded.BorderBottom.Width != UnsetWidth {
-			p.gp.SetLineWidth(decoded.BorderBottom.Width)
-			p.gp.SetStrokeColor(decoded.BorderBottom.Color.R, decoded.BorderBottom.Color.G, decoded.BorderBottom.Color.B)
-			p.gp.Line(textFrame.MinX()+textFrame.Width(), textFrame.MinY()+textFrame.Height(), textFrame.MinX(), textFrame.MinY()+textFrame.Height())
-		}
-		if decoded.BorderLeft.Width != UnsetWidth {
-			p.gp.SetLineWidth(decoded.BorderLeft.Width)
-			p.gp.SetStrokeColor(decoded.BorderLeft.Color.R, decoded.BorderLeft.Color.G, decoded.BorderLeft.Color.B)
-			p.gp.Line(textFrame.MinX(), textFrame.MinY()+textFrame.Height(), textFrame.MinX(), textFrame.MinY())
-		}
+// テキスト要素の初期値
+func newElementText(documentConfigure types.DocumentConfigure) types.ElementText {
+	return types.ElementText{
+		TextSize:     documentConfigure.TextSize,
+		Color:        documentConfigure.TextColor,
+		Size:         types.Size{Width: UnsetWidth, Height: UnsetWidth},
+		Origin:       types.Origin{X: UnsetWidth, Y: UnsetHeight},
+		Border:       types.Border{Width: UnsetWidth, Color: types.Color{R: DefaultColorR, B: DefaultColorG, G: DefaultColorB}},
+		BorderTop:    types.Border{Width: UnsetWidth, Color: types.Color{R: DefaultColorR, B: DefaultColorG, G: DefaultColorB}},
+		BorderRight:  types.Border{Width: UnsetWidth, Color: types.Color{R: DefaultColorR, B: DefaultColorG, G: DefaultColorB}},
+		BorderBottom: types.Border{Width: UnsetWidth, Color: types.Color{R: DefaultColorR, B: DefaultColorG, G: DefaultColorB}},
+		BorderLeft:   types.Border{Width: UnsetWidth, Color: types.Color{R: DefaultColorR, B: DefaultColorG, G: DefaultColorB}},
 	}
+}
 
-	if decoded.BackgroundColor.R != DefaultColorR || decoded.BackgroundColor.G != DefaultColorG || decoded.BackgroundColor.B != DefaultColorB {
-		p.gp.SetFillColor(decoded.BackgroundColor.R, decoded.BackgroundColor.G, decoded.BackgroundColor.B)
-		p.gp.RectFromUpperLeftWithStyle(textFrame.MinX(), textFrame.MinY(), textFrame.Width(), textFrame.Height(), "F")
+// 画像要素の初期値
+func newElementImage() types.ElementImage {
+	return types.ElementImage{
+		Size:       types.Size{Width: UnsetWidth, Height: UnsetWidth},
+		Origin:     types.Origin{X: UnsetWidth, Y: UnsetHeight},
+		Resize:     false,
+		Resolution: DefaultImageResolution,
 	}
+}
 
-	var gpRect = gopdf.Rect{W: textRect.Width(), H: textRect.Height()}
-
-	// WRAP TEXT
-	if decoded.Wrap {
-		p.gp.SetTextColor(decoded.Color.R, decoded.Color.G, decoded.Color.B)
-		_ = p.gp.MultiCell(&gpRect, decoded.Text)
-		p.gp.SetTextColor(documentConfigure.TextColor.R, documentConfigure.TextColor.G, documentConfigure.TextColor.B)
-		return
+// 描画：テキスト
+func (p *PDF) drawText(documentConfigure types.DocumentConfigure, decoded types.ElementText, textRect types.Rect, textFrame types.Rect) error {
+	// FILL
+	if decoded.BackgroundColor != nil {
+		if err := p.fillRoundedRect(*decoded.BackgroundColor, textFrame, decoded.BorderRadius); err != nil {
+			return err
+		}
 	}
 
-	// TEXT OPTION
-	var option = gopdf.CellOption{
-		Border: 0,
-		Float:  gopdf.Right,
-	}
-	if decoded.Align.IsCenter() {
-		option.Align = gopdf.Center
-	} else if decoded.Align.IsRight() {
-		option.Align = gopdf.Right
-	} else {
-		option.Align = gopdf.Left
+	// BORDER
+	if decoded.Border.Width != UnsetWidth {
+		if err := p.drawFrameBorder(decoded.Border, textFrame, decoded.BorderRadius); err != nil {
+			return err
+		}
+	} else if err := p.drawFrameBorders(decoded.BorderTop, decoded.BorderRight, decoded.BorderBottom, decoded.BorderLeft, textFrame); err != nil {
+		return err
 	}
-	if decoded.Valign.IsMiddle() {
-		option.Align = option.Align | gopdf.Middle
-	} else if decoded.Valign.IsBottom() {
-		option.Align = option.Align | gopdf.Bottom
-	} else {
-		option.Align = option.Align | gopdf.Top
+
+	// FIT
+	decoded, err := p.fitText(documentConfigure, decoded, textRect)
+	if err != nil {
+		return err
 	}
 
 	// TEXT SIZE
-	if err := p.gp.SetFont("default", "", decoded.TextSize); err != nil {
-		log.Print(err.Error())
+	if _, err := p.setFont(decoded.FontFamily, decoded.FontStyle, decoded.TextSize); err != nil {
+		return err
 	}
 
 	// TEXT COLOR
-	p.gp.SetFillColor(decoded.Color.R, decoded.Color.G, decoded.Color.B)
-	p.gp.SetTextColor(decoded.Color.R, decoded.Color.G, decoded.Color.B)
+	p.setFillColor(decoded.Color)
+	p.setTextColor(decoded.Color)
+
+	// VERTICAL TEXT
+	if decoded.WritingMode.IsVertical() {
+		if err := p.drawVerticalText(decoded, textRect); err != nil {
+			return err
+		}
+	} else if decoded.Wrap {
+		// WRAP TEXT
+		lines, err := p.wrapText(p.textSpans(decoded), textRect.Width())
+		if err != nil {
+			return err
+		}
+
+		// 枠に収まる行
+		var totalLineHeight float64
+		for i, line := range lines {
+			lineHeight := decoded.LineHeight.Apply(line.height())
+			if i > 0 && lines[i-1].end {
+				lineHeight += decoded.ParagraphSpacing
+			}
+			if totalLineHeight+lineHeight > textRect.Height() {
+				lines = lines[:i]
+				break
+			}
+			totalLineHeight += lineHeight
+		}
 
-	if p.isMultiLineText(decoded.Text) {
-		option.Float = gopdf.Bottom
-		texts := strings.Split(decoded.Text, "\n")
-		gpRect = gopdf.Rect{W: textRect.Width(), H: textRect.Height() / float64(len(texts))}
-		for _, text := range texts {
-			_ = p.gp.CellWithOption(&gpRect, text, option)
+		y := textRect.MinY()
+		if decoded.Valign.IsMiddle() {
+			y += (textRect.Height() - totalLineHeight) * 0.5
+		} else if decoded.Valign.IsBottom() {
+			y += textRect.Height() - totalLineHeight
+		}
+		for i, line := range lines {
+			if i > 0 && lines[i-1].end {
+				y += decoded.ParagraphSpacing
+			}
+			if decoded.Align.IsJustify() {
+				if line, err = p.justifyLine(line, textRect.Width()); err != nil {
+					return err
+				}
+			}
+			// 行の高さの余りは上下に均等に空ける
+			lineHeight := decoded.LineHeight.Apply(line.height())
+			baseline := y + (lineHeight-line.ascent()+line.descent())*0.5 + line.ascent()
+			if err := p.drawLine(line, p.alignLine(line, textRect, decoded.Align), baseline); err != nil {
+				return err
+			}
+			y += lineHeight
 		}
 	} else {
-		_ = p.gp.CellWithOption(&gpRect, decoded.Text, option)
+		paragraphs := splitParagraphs(p.textSpans(decoded))
+		if decoded.LineHeight.IsZero() && decoded.ParagraphSpacing == 0 {
+			// 枠を行数で等分する
+			lineRect := types.Rect{Origin: textRect.Origin, Size: types.Size{Width: textRect.Width(), Height: textRect.Height() / float64(len(paragraphs))}}
+			for _, paragraph := range paragraphs {
+				line, err := p.layoutLine(paragraph)
+				if err != nil {
+					return err
+				}
+				if err := p.drawLine(line, p.alignLine(line, lineRect, decoded.Align), p.baselineLine(line, lineRect, decoded.Valign)); err != nil {
+					return err
+				}
+				lineRect.Origin.Y += lineRect.Height()
+			}
+		} else {
+			// 行の高さと段落の間隔で積む
+			var lines []textLine
+			var totalLineHeight float64
+			for i, paragraph := range paragraphs {
+				line, err := p.layoutLine(paragraph)
+				if err != nil {
+					return err
+				}
+				if i > 0 {
+					totalLineHeight += decoded.ParagraphSpacing
+				}
+				totalLineHeight += decoded.LineHeight.Apply(line.height())
+				lines = append(lines, line)
+			}
+
+			lineRect := types.Rect{Origin: textRect.Origin, Size: types.Size{Width: textRect.Width()}}
+			if decoded.Valign.IsMiddle() {
+				lineRect.Origin.Y += (textRect.Height() - totalLineHeight) * 0.5
+			} else if decoded.Valign.IsBottom() {
+				lineRect.Origin.Y += textRect.Height() - totalLineHeight
+			}
+			for i, line := range lines {
+				if i > 0 {
+					lineRect.Origin.Y += decoded.ParagraphSpacing
+				}
+				lineRect.Size.Height = decoded.LineHeight.Apply(line.height())
+				if err := p.drawLine(line, p.alignLine(line, lineRect, decoded.Align), p.baselineLine(line, lineRect, types.ValignMiddle)); err != nil {
+					return err
+				}
+				lineRect.Origin.Y += lineRect.Height()
+			}
+		}
 	}
 
 	// RESET COLOR
-	p.gp.SetStrokeColor(documentConfigure.TextColor.R, documentConfigure.TextColor.G, documentConfigure.TextColor.B)
-	p.gp.SetFillColor(documentConfigure.TextColor.R, documentConfigure.TextColor.G, documentConfigure.TextColor.B)
-	p.gp.SetTextColor(documentConfigure.TextColor.R, documentConfigure.TextColor.G, documentConfigure.TextColor.B)
+	p.setStrokeColor(documentConfigure.TextColor)
+	p.setFillColor(documentConfigure.TextColor)
+	p.setTextColor(documentConfigure.TextColor)
+
+	return nil
 }
 
 // 描画：画像
-func (p *PDF) drawImage(documentConfigure types.DocumentConfigure, decoded types.ElementImage, imageRect types.Rect, imageFrame types.Rect) {
-	file, _ := os.Open(decoded.Path)
-	img, imgType, _ := image.Decode(file)
+func (p *PDF) drawImage(documentConfigure types.DocumentConfigure, decoded types.ElementImage, imageRect types.Rect, imageFrame types.Rect) error {
+	file, err := os.Open(decoded.Path)
+	if err != nil {
+		return p.imageDecodeError(err, decoded.Path)
+	}
+	img, imgType, err := image.Decode(file)
 	_ = file.Close()
+	if err != nil {
+		return p.imageDecodeError(err, decoded.Path)
+	}
 
 	var imageHoloder gopdf.ImageHolder
 
-	// RESIZE
-	if decoded.Resize {
+	// CLIP
+	if !decoded.BorderRadius.IsZero() {
+		clipImg := img
+		if decoded.Resize {
+			clipImg = resize.Resize(uint(imageRect.Width())*decoded.Resolution, uint(imageRect.Height())*decoded.Resolution, img, resize.Lanczos3)
+		}
+
+		clippedBuf := new(bytes.Buffer)
+		// 枠線と同じ枠の角丸を余白の分だけ内側に縮めた形で切り抜く
+		clipRect := imageFrame.ApplyMargin(decoded.Margin)
+		clipRadius := decoded.BorderRadius.Fit(imageFrame.Size).Inset(decoded.Margin)
+		if err := png.Encode(clippedBuf, clipRoundedImage(clipImg, imageRect, clipRect, clipRadius)); err != nil {
+			return p.imageDecodeError(err, decoded.Path)
+		}
+
+		_imageHolder, err := gopdf.ImageHolderByBytes(clippedBuf.Bytes())
+		if err != nil {
+			return p.imageDecodeError(err, decoded.Path)
+		}
+
+		imageHoloder = _imageHolder
+	} else if decoded.Resize {
+		// RESIZE
 		resizedImg := resize.Resize(uint(imageRect.Width())*decoded.Resolution, uint(imageRect.Height())*decoded.Resolution, img, resize.Lanczos3)
 
 		resizedBuf := new(bytes.Buffer)
 		switch imgType {
 		case "png":
 			if err := png.Encode(resizedBuf, resizedImg); err != nil {
-				panic(err)
+				return p.imageDecodeError(err, decoded.Path)
 			}
 		case "jpeg":
 			if err := jpeg.Encode(resizedBuf, resizedImg, nil); err != nil {
-				panic(err)
+				return p.imageDecodeError(err, decoded.Path)
 			}
 		}
 
 		_imageHolder, err := gopdf.ImageHolderByBytes(resizedBuf.Bytes())
 		if err != nil {
-			panic(err)
+			return p.imageDecodeError(err, decoded.Path)
 		}
 
 		imageHoloder = _imageHolder
 	} else {
 		_imageHolder, err := gopdf.ImageHolderByPath(decoded.Path)
 		if err != nil {
-			panic(err)
+			return p.imageDecodeError(err, decoded.Path)
 		}
 
 		imageHoloder = _imageHolder
@@ -778,30 +1155,61 @@ func (p *PDF) drawImage(documentConfigure types.DocumentConfigure, decoded types
 
 	// DRAW IMAGE
 	var gpRect = gopdf.Rect{W: imageRect.Width(), H: imageRect.Height()}
-	_ = p.gp.ImageByHolder(imageHoloder, imageRect.MinX(), imageRect.MinY(), &gpRect)
+	if err := p.drawWithAlpha(decoded.Alpha(), func() error {
+		if err := p.gp.ImageByHolderWithOptions(imageHoloder, gopdf.ImageOptions{X: imageRect.MinX(), Y: imageRect.MinY(), Rect: &gpRect}); err != nil {
+			return p.imageDecodeError(err, decoded.Path)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
 
 	// BORDER
 	if decoded.Border.Width != UnsetWidth {
-		p.gp.SetLineWidth(decoded.Border.Width)
-		p.gp.SetStrokeColor(decoded.Border.Color.R, decoded.Border.Color.G, decoded.Border.Color.B)
-		p.gp.RectFromUpperLeft(imageFrame.MinX(), imageFrame.MinY(), imageFrame.Width(), imageFrame.Height())
-	} else if decoded.BorderTop.Width != UnsetWidth {
-		p.gp.SetLineWidth(decoded.BorderTop.Width)
-		p.gp.SetStrokeColor(decoded.BorderTop.Color.R, decoded.BorderTop.Color.G, decoded.BorderTop.Color.B)
-		p.gp.Line(imageFrame.MinX(), imageFrame.MinY(), imageFrame.MinX()+imageFrame.Width(), imageFrame.MinY())
-	} else if decoded.BorderRight.Width != UnsetWidth {
-		p.gp.SetLineWidth(decoded.BorderRight.Width)
-		p.gp.SetStrokeColor(decoded.BorderRight.Color.R, decoded.BorderRight.Color.G, decoded.BorderRight.Color.B)
-		p.gp.Line(imageFrame.MinX()+imageFrame.Width(), imageFrame.MinY(), imageFrame.MinX()+imageFrame.Width(), imageFrame.MinY()+imageFrame.Height())
-	} else if decoded.BorderBottom.Width != UnsetWidth {
-		p.gp.SetLineWidth(decoded.BorderBottom.Width)
-		p.gp.SetStrokeColor(decoded.BorderBottom.Color.R, decoded.BorderBottom.Color.G, decoded.BorderBottom.Color.B)
-		p.gp.Line(imageFrame.MinX()+imageFrame.Width(), imageFrame.MinY()+imageFrame.Height(), imageFrame.MinX(), imageFrame.MinY()+imageFrame.Height())
-	} else if decoded.BorderLeft.Width != UnsetWidth {
-		p.gp.SetLineWidth(decoded.BorderLeft.Width)
-		p.gp.SetStrokeColor(decoded.BorderLeft.Color.R, decoded.BorderLeft.Color.G, decoded.BorderLeft.Color.B)
-		p.gp.Line(imageFrame.MinX(), imageFrame.MinY()+imageFrame.Height(), imageFrame.MinX(), imageFrame.MinY())
+		if err := p.drawFrameBorder(decoded.Border, imageFrame, decoded.BorderRadius); err != nil {
+			return err
+		}
+	} else if err := p.drawFrameBorders(decoded.BorderTop, decoded.BorderRight, decoded.BorderBottom, decoded.BorderLeft, imageFrame); err != nil {
+		return err
 	}
+
+	return nil
+}
+
+// 描画状態の保存（gopdf には q を出力する関数がないため、角度 0 の回転で代用する）
+func (p *PDF) saveState() {
+	p.gp.Rotate(0, 0, 0)
+}
+
+// 描画状態の復元
+func (p *PDF) restoreState() {
+	p.gp.RotateReset()
+}
+
+// 描画：要素の回転（rotation は時計回りの角度、point を中心に回す）
+func (p *PDF) drawRotated(rotation float64, point types.Origin, draw func() error) error {
+	if rotation == 0 {
+		return draw()
+	}
+	p.gp.Rotate(-rotation, point.X, point.Y)
+	err := draw()
+	p.gp.RotateReset()
+	return err
+}
+
+// 計算：回転後の外接矩形の大きさ
+func rotatedSize(size types.Size, rotation float64) types.Size {
+	if rotation == 0 {
+		return size
+	}
+	radian := rotation * math.Pi / 180
+	cos, sin := math.Abs(math.Cos(radian)), math.Abs(math.Sin(radian))
+	return types.Size{Width: size.Width*cos + size.Height*sin, Height: size.Width*sin + size.Height*cos}
+}
+
+// 計算：矩形の中央に置いた矩形
+func centerRect(rect types.Rect, size types.Size) types.Rect {
+	return types.Rect{Origin: types.Origin{X: rect.MinX() + (rect.Width()-size.Width)*0.5, Y: rect.MinY() + (rect.Height()-size.Height)*0.5}, Size: size}
 }
 
 // 縦
@@ -846,9 +1254,9 @@ func (p *PDF) breakPage(lineWrapRect *types.Rect, wrapRect *types.Rect) {
 	wrapRect.Size.Height = 0
 }
 
-// 判定：複数行
-func (p *PDF) isMultiLineText(text string) bool {
-	return strings.Contains(text, "\n")
+// 判定：ページの先頭（ページヘッダー・固定タイトルの下）の位置
+func (p *PDF) isPageTop(y float64) bool {
+	return y <= p.pageTop
 }
 
 // 判定：改行
@@ -866,3 +1274,18 @@ func (p *PDF) needPageBreak(lineWrapRect types.Rect, measureSize types.Size) boo
 	}
 	return false
 }
+
+// 現在のページ番号（0始まり）
+func (p *PDF) pageIndex() int {
+	return int(p.pageNumber) - 1
+}
+
+// エラー：ページに収まらない
+func (p *PDF) overflowError(path string, size types.Size, lineWrapRect types.Rect) error {
+	return &LayoutOverflowError{
+		PageIndex: p.pageIndex(),
+		Path:      path,
+		Size:      size,
+		Available: types.Size{Width: p.contentRect.MaxX() - lineWrapRect.MinX(), Height: p.contentRect.MaxY() - lineWrapRect.MinY()},
+	}
+}
@@ -0,0 +1,303 @@
+package pdf
+
+import (
+	"apple-x-co/go-pdf/types"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/signintech/gopdf"
+	"github.com/signintech/gopdf/fontmaker/core"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testFontPath = "testdata/LiberationSerif-Regular.ttf"
+
+// 描画：テスト用のレイアウト JSON
+func renderTestLayout(t *testing.T, layout string, data interface{}) ([]byte, error) {
+	t.Helper()
+	var out bytes.Buffer
+	err := Render(context.Background(), strings.NewReader(layout), &out, Options{TTFPath: testFontPath, Data: data})
+	return out.Bytes(), err
+}
+
+// テスト用のフォントを読み込んだ PDF
+func newTestFontPDF(t *testing.T, documentConfigure types.DocumentConfigure) *PDF {
+	t.Helper()
+	p := newTestPDF(t)
+	if err := p.loadFonts(documentConfigure); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.addFonts(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// 描画したテキストの TJ（glyph ID はフォントの cmap の値）
+func textTJ(t *testing.T, fontPath string, text string) []byte {
+	t.Helper()
+	var parser core.TTFParser
+	if err := parser.Parse(fontPath); err != nil {
+		t.Fatal(err)
+	}
+	var hex string
+	for _, r := range text {
+		hex += fmt.Sprintf("%04X", parser.Chars()[int(r)])
+	}
+	return []byte("[<" + hex + ">] TJ")
+}
+
+// 出力した PDF のページ数
+func countPages(b []byte) int {
+	return bytes.Count(b, []byte("/Type /Page\n"))
+}
+
+// 圧縮せずに描画内容を確認できる PDF
+func newTestPDF(t *testing.T) *PDF {
+	t.Helper()
+	p := &PDF{}
+	p.gp.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4})
+	p.gp.SetNoCompression()
+	p.gp.AddPage()
+	return p
+}
+
+func TestDrawRotated(t *testing.T) {
+	tests := []struct {
+		name     string
+		rotation float64
+		want     string
+	}{
+		{name: "90", rotation: 90, want: "q\n 0.00000 -1.00000 1.00000\n 0.00000 100.00 642.00 cm\n"},
+		{name: "30", rotation: 30, want: "q\n 0.86603 -0.50000 0.50000\n 0.86603 100.00 642.00 cm\n"},
+		{name: "-45", rotation: -45, want: "q\n 0.70711 0.70711 -0.70711\n 0.70711 100.00 642.00 cm\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newTestPDF(t)
+			if err := p.drawRotated(test.rotation, types.Origin{X: 100, Y: 200}, func() error {
+				p.gp.Line(0, 0, 10, 10)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if got := p.gp.GetBytesPdf(); !bytes.Contains(got, []byte(test.want)) {
+				t.Errorf("drawRotated(%v) does not write %q", test.rotation, test.want)
+			}
+		})
+	}
+}
+
+func TestNewElementTextColor(t *testing.T) {
+	alpha := 0.5
+	tests := []struct {
+		name  string
+		color types.Color
+	}{
+		{name: "rgb", color: types.Color{R: 1, G: 2, B: 3}},
+		{name: "alpha", color: types.Color{R: 1, G: 2, B: 3, A: &alpha}},
+		{name: "cmyk", color: types.Color{CMYK: &types.CMYK{C: 10, M: 20, Y: 30, K: 40}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := newElementText(types.DocumentConfigure{TextColor: test.color}).Color; !reflect.DeepEqual(got, test.color) {
+				t.Errorf("newElementText().Color = %+v, want %+v", got, test.color)
+			}
+		})
+	}
+}
+
+func TestAttributeDecodeError(t *testing.T) {
+	tests := []struct {
+		name      string
+		layout    string
+		pageIndex int
+		path      string
+	}{
+		{
+			name:      "invalid literal color",
+			layout:    `{"width": 400, "height": 400, "pages": [{"liner_layout": {"elements": [{"type": "text", "attributes": {"text": "a", "color": "#zz0000"}}]}}]}`,
+			pageIndex: 0,
+			path:      "pages[0].liner_layout.elements[0]",
+		},
+		{
+			name:      "invalid template attribute",
+			layout:    `{"width": 400, "height": 400, "templates": [{"type": "text", "id": "t1", "attributes": {"size": "large"}}], "pages": [{"liner_layout": {"elements": [{"type": "text", "template_id": "t1", "attributes": {"text": "a"}}]}}]}`,
+			pageIndex: 0,
+			path:      "pages[0].liner_layout.elements[0].template_id",
+		},
+		{
+			name:      "unknown palette name",
+			layout:    `{"width": 400, "height": 400, "palette": {"primary": "#ff0000"}, "pages": [{"liner_layout": {"elements": [{"type": "text", "attributes": {"text": "a", "color": "primary"}}, {"type": "table", "attributes": {"columns": [{"type": "auto"}], "rows": [{"cells": [{"text": "a", "color": "secondary"}]}]}}]}}]}`,
+			pageIndex: 0,
+			path:      "pages[0].liner_layout.elements[1]",
+		},
+		{
+			name:      "unknown document color",
+			layout:    `{"width": 400, "height": 400, "text_color": "secondary", "pages": [{"liner_layout": {"elements": []}}]}`,
+			pageIndex: -1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := renderTestLayout(t, test.layout, nil)
+			var decodeErr *AttributeDecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("Render() error = %v, want AttributeDecodeError", err)
+			}
+			if decodeErr.PageIndex != test.pageIndex || decodeErr.Path != test.path {
+				t.Errorf("Render() error at (%d, %q), want (%d, %q)", decodeErr.PageIndex, decodeErr.Path, test.pageIndex, test.path)
+			}
+		})
+	}
+}
+
+func TestRepeat(t *testing.T) {
+	data := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"name": "A", "lines": []interface{}{"a1", "a2"}},
+			map[string]interface{}{"name": "B", "lines": []interface{}{"b1"}},
+		},
+	}
+	layout := `{
+  "width": 400, "height": 400, "compress_level": 0,
+  "pages": [{"liner_layout": {"orientation": "vertical", "liner_layouts": [
+    {"repeat": "Data.items", "orientation": "vertical", "liner_layouts": [
+      {"elements": [{"type": "text", "attributes": {"text": "{{.Index}}:{{.Item.name}}"}}]},
+      {"repeat": "Item.lines", "elements": [{"type": "text", "attributes": {"text": "-{{.Item}}"}}]}
+    ]}
+  ]}}]
+}`
+	b, err := renderTestLayout(t, layout, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var positions []int
+	for _, text := range []string{"0:A", "-a1", "-a2", "1:B", "-b1"} {
+		position := bytes.Index(b, textTJ(t, testFontPath, text))
+		if position < 0 {
+			t.Fatalf("output does not contain %q", text)
+		}
+		positions = append(positions, position)
+	}
+	for i := 1; i < len(positions); i++ {
+		if positions[i-1] > positions[i] {
+			t.Errorf("texts are not drawn in data order")
+		}
+	}
+
+	_, err = renderTestLayout(t, `{"width": 400, "height": 400, "pages": [{"liner_layout": {"liner_layouts": [{"repeat": "Items", "elements": []}]}}]}`, data)
+	var templateErr *TemplateParseError
+	if !errors.As(err, &templateErr) || templateErr.Path != "pages[0].liner_layout.liner_layouts[0].repeat" {
+		t.Errorf("Render() error = %v, want TemplateParseError at repeat", err)
+	}
+}
+
+func TestRepeatPageBreak(t *testing.T) {
+	var items []interface{}
+	for i := 0; i < 30; i++ {
+		items = append(items, i)
+	}
+	b, err := renderTestLayout(t, `{
+  "width": 400, "height": 400, "compress_level": 0,
+  "pages": [{"liner_layout": {"orientation": "vertical", "liner_layouts": [
+    {"repeat": "Data.items", "elements": [{"type": "text", "attributes": {"text": "row {{.Item}}", "size": {"width": 100, "height": 40}}}]}
+  ]}}]
+}`, map[string]interface{}{"items": items})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 本文 380 のページに 9 行ずつ
+	if got := countPages(b); got != 4 {
+		t.Errorf("pages = %d, want 4", got)
+	}
+	if !bytes.Contains(b, textTJ(t, testFontPath, "row 29")) {
+		t.Errorf("output does not contain the last row")
+	}
+}
+
+func TestVisibleIf(t *testing.T) {
+	layout := `{
+  "width": 400, "height": 400, "compress_level": 0,
+  "pages": [{"liner_layout": {"orientation": "vertical", "liner_layouts": [
+    {"elements": [
+      {"type": "text", "attributes": {"text": "Total"}},
+      {"type": "text", "visible_if": "{{.Data.discount}}", "attributes": {"text": "Discount"}}
+    ]},
+    {"visible_if": "{{.Data.note}}", "elements": [{"type": "text", "attributes": {"text": "Note"}}]}
+  ]}}]
+}`
+	tests := []struct {
+		name    string
+		data    map[string]interface{}
+		visible []string
+		hidden  []string
+	}{
+		{name: "no data", data: map[string]interface{}{}, visible: []string{"Total"}, hidden: []string{"Discount", "Note"}},
+		{name: "zero", data: map[string]interface{}{"discount": 0, "note": false}, visible: []string{"Total"}, hidden: []string{"Discount", "Note"}},
+		{name: "values", data: map[string]interface{}{"discount": 100, "note": "text"}, visible: []string{"Total", "Discount", "Note"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := renderTestLayout(t, layout, test.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, text := range test.visible {
+				if !bytes.Contains(b, textTJ(t, testFontPath, text)) {
+					t.Errorf("output does not contain %q", text)
+				}
+			}
+			for _, text := range test.hidden {
+				if bytes.Contains(b, textTJ(t, testFontPath, text)) {
+					t.Errorf("output contains %q", text)
+				}
+			}
+		})
+	}
+}
+
+func TestVisibleIfPageNumber(t *testing.T) {
+	page := `{"liner_layout": {"elements": [{"type": "text", "visible_if": "{{gt .PageNumber 1}}", "attributes": {"text": "Continued"}}]}}`
+	b, err := renderTestLayout(t, `{"width": 400, "height": 400, "compress_level": 0, "pages": [`+page+`, `+page+`]}`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := countPages(b); got != 2 {
+		t.Errorf("pages = %d, want 2", got)
+	}
+	if got := bytes.Count(b, textTJ(t, testFontPath, "Continued")); got != 1 {
+		t.Errorf("drawn %d times, want only on the second page", got)
+	}
+}
+
+func TestTotalPages(t *testing.T) {
+	page := `{"liner_layout": {"elements": [{"type": "text", "attributes": {"text": "{{.PageNumber}}/{{.TotalPages}}"}}]}}`
+	b, err := renderTestLayout(t, `{"width": 400, "height": 400, "compress_level": 0, "pages": [`+page+`, `+page+`, `+page+`]}`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"1/3", "2/3", "3/3"} {
+		if !bytes.Contains(b, textTJ(t, testFontPath, text)) {
+			t.Errorf("output does not contain %q", text)
+		}
+	}
+}
+
+func TestPageCountError(t *testing.T) {
+	// 総ページ数が 2 のときだけ 1 ページに収まるため、ページ数が確定しない
+	_, err := renderTestLayout(t, `{
+  "width": 400, "height": 400,
+  "pages": [{"liner_layout": {"orientation": "vertical", "elements": [
+    {"type": "text", "attributes": {"text": "spacer", "size": {"width": 100, "height": 300}}},
+    {"type": "text", "visible_if": "{{ne .TotalPages 2}}", "attributes": {"text": "extra", "size": {"width": 100, "height": 100}}}
+  ]}}]
+}`, nil)
+	var pageCountErr *PageCountError
+	if !errors.As(err, &pageCountErr) || pageCountErr.Passes != MaxLayoutPasses {
+		t.Errorf("Render() error = %v, want PageCountError", err)
+	}
+}
@@ -0,0 +1,50 @@
+package pdf
+
+import (
+	"apple-x-co/go-pdf/types"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+)
+
+type Options struct {
+	TTFPath string
+	Data    interface{}
+}
+
+// レイアウトJSONを読み込み、PDFを書き出す
+func Render(ctx context.Context, layout io.Reader, out io.Writer, options Options) error {
+	b, err := ioutil.ReadAll(layout)
+	if err != nil {
+		return err
+	}
+
+	var documentConfigure = NewDocumentConfigure(options.TTFPath)
+	if err := json.Unmarshal(b, &documentConfigure); err != nil {
+		return err
+	}
+	documentConfigure.Data = options.Data
+
+	document := PDF{}
+	defer document.Destroy()
+	if err := document.DrawContext(ctx, documentConfigure); err != nil {
+		return err
+	}
+
+	return document.Write(out)
+}
+
+// 既定値を設定したドキュメント設定
+func NewDocumentConfigure(ttfPath string) types.DocumentConfigure {
+	return types.DocumentConfigure{
+		Margin:        types.Margin{Top: 10, Right: 10, Bottom: 10, Left: 10},
+		TextSize:      DefaultTextSize,
+		TextColor:     types.Color{R: DefaultColorR, G: DefaultColorG, B: DefaultColorB},
+		AutoPageBreak: true,
+		CompressLevel: DefaultCompressLevel,
+		TTFPath:       ttfPath,
+		CommonHeader:  types.Header{Size: types.Size{Width: UnsetWidth, Height: UnsetHeight}},
+		CommonFooter:  types.Footer{Size: types.Size{Width: UnsetWidth, Height: UnsetHeight}},
+	}
+}
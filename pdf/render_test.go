@@ -0,0 +1,57 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	layout := `{
+  "width": 400, "height": 400, "compress_level": 0,
+  "pages": [{"liner_layout": {"elements": [{"type": "text", "attributes": {"text": "Hello {{.Data.name}}"}}]}}]
+}`
+	var out bytes.Buffer
+	if err := Render(context.Background(), strings.NewReader(layout), &out, Options{TTFPath: testFontPath, Data: map[string]interface{}{"name": "World"}}); err != nil {
+		t.Fatal(err)
+	}
+	b := out.Bytes()
+	if !bytes.HasPrefix(b, []byte("%PDF-")) {
+		t.Errorf("output is not a PDF: %q", b[:10])
+	}
+	if got := countPages(b); got != 1 {
+		t.Errorf("pages = %d, want 1", got)
+	}
+	if !bytes.Contains(b, textTJ(t, testFontPath, "Hello World")) {
+		t.Errorf("output does not contain the text with the bound data")
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		layout string
+		want   error
+	}{
+		{name: "invalid json", ctx: context.Background(), layout: `{"pages": [`},
+		{name: "canceled", ctx: ctx, layout: `{"width": 400, "height": 400, "pages": [{"liner_layout": {"elements": []}}]}`, want: context.Canceled},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := Render(test.ctx, strings.NewReader(test.layout), &out, Options{TTFPath: testFontPath})
+			if err == nil || (test.want != nil && !errors.Is(err, test.want)) {
+				t.Fatalf("Render() error = %v, want %v", err, test.want)
+			}
+			if out.Len() != 0 {
+				t.Errorf("Render() wrote %d bytes on error", out.Len())
+			}
+		})
+	}
+}
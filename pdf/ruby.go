@@ -0,0 +1,63 @@
+package pdf
+
+import (
+	"apple-x-co/go-pdf/types"
+	"math"
+	"regexp"
+)
+
+// ルビの記法：{親文字|ルビ}
+var rubyPattern = regexp.MustCompile(`\{([^{}|\n]+)\|([^{}|\n]+)\}`)
+
+// ルビの文字サイズ（親文字に対する割合）
+const rubySizeRatio = 0.5
+
+// 分割：ルビの付いた文字列（ルビが長い場合は広い方の幅を使う）
+func (p *PDF) layoutRuby(baseText string, rubyText string, span textSpan) (textRun, error) {
+	var baseSpan, rubySpan = span, span
+	baseSpan.text = baseText
+	rubySpan.text = rubyText
+	rubySpan.size = span.size * rubySizeRatio
+	rubySpan.spacing = 0
+	rubySpan.style.decoration = types.TextDecoration{}
+	baseSpan.style.backgroundColor = nil
+	rubySpan.style.backgroundColor = nil
+
+	base, err := p.layoutLine([]textSpan{baseSpan})
+	if err != nil {
+		return textRun{}, err
+	}
+	ruby, err := p.layoutLine([]textSpan{rubySpan})
+	if err != nil {
+		return textRun{}, err
+	}
+	return textRun{
+		text:  baseText,
+		font:  base.font,
+		size:  span.size,
+		width: math.Max(base.width, ruby.width),
+		style: span.style,
+		base:  &base,
+		ruby:  &ruby,
+	}, nil
+}
+
+// 行のルビの高さ
+func (l textLine) rubyHeight() float64 {
+	var height float64
+	for _, run := range l.runs {
+		if run.ruby != nil && height < run.ruby.ascent()-run.ruby.descent() {
+			height = run.ruby.ascent() - run.ruby.descent()
+		}
+	}
+	return height
+}
+
+// 行の文字列
+func (l textLine) text() string {
+	var text string
+	for _, run := range l.runs {
+		text += run.text
+	}
+	return text
+}
@@ -0,0 +1,59 @@
+package pdf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRubyPattern(t *testing.T) {
+	tests := []struct {
+		text string
+		want [][2]string
+	}{
+		{text: "{漢字|かんじ}", want: [][2]string{{"漢字", "かんじ"}}},
+		{text: "これは{漢字|かんじ}です", want: [][2]string{{"漢字", "かんじ"}}},
+		{text: "{東|ひがし}{京|きょう}", want: [][2]string{{"東", "ひがし"}, {"京", "きょう"}}},
+		{text: "{{漢|かん}}", want: [][2]string{{"漢", "かん"}}},
+		{text: "漢字", want: nil},
+		{text: "{漢字}", want: nil},
+		{text: "{漢字|}", want: nil},
+		{text: "{|かんじ}", want: nil},
+		{text: "{漢|か|ん}", want: nil},
+		{text: "{漢\n字|かんじ}", want: nil},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			var got [][2]string
+			for _, match := range rubyPattern.FindAllStringSubmatch(test.text, -1) {
+				got = append(got, [2]string{match[1], match[2]})
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("rubyPattern(%q) = %q, want %q", test.text, got, test.want)
+			}
+		})
+	}
+}
+
+func TestTextLineRubyHeight(t *testing.T) {
+	f := font{ascender: 800, descender: -200}
+	ruby := func(size float64) textRun {
+		return textRun{font: f, size: size * 2, base: &textLine{font: f, size: size * 2}, ruby: &textLine{font: f, size: size}}
+	}
+	tests := []struct {
+		name string
+		runs []textRun
+		want float64
+	}{
+		{name: "no ruby", runs: []textRun{{font: f, size: 10}}, want: 0},
+		{name: "ruby", runs: []textRun{{font: f, size: 10}, ruby(5)}, want: 5},
+		{name: "largest ruby", runs: []textRun{ruby(5), ruby(8), ruby(6)}, want: 8},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			line := textLine{runs: test.runs, font: f, size: 10}
+			if got := line.rubyHeight(); got != test.want {
+				t.Errorf("rubyHeight() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
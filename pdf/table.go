@@ -0,0 +1,330 @@
+package pdf
+
+import (
+	"apple-x-co/go-pdf/types"
+	"fmt"
+)
+
+type tableCell struct {
+	types.TableCell
+	row   int
+	col   int
+	lines []textLine
+}
+
+type tableLayout struct {
+	cells        []tableCell
+	columnWidths []float64
+	rowHeights   []float64
+	groups       [][2]int
+}
+
+// セルのテキストの高さ（テキスト要素と同じく、フォールバックしたフォントやルビのある行は高くなる）
+func (c tableCell) textHeight() float64 {
+	var height float64
+	for _, line := range c.lines {
+		height += line.height()
+	}
+	return height
+}
+
+// セルの書式
+func (c tableCell) spans() []textSpan {
+	return []textSpan{{text: c.Text, fontFamily: c.FontFamily, fontStyle: c.FontStyle, size: float64(c.TextSize), style: textStyle{color: *c.Color}}}
+}
+
+func (t *tableLayout) width() float64 {
+	return sum(t.columnWidths, 0, len(t.columnWidths))
+}
+
+func (t *tableLayout) height(from int, to int) float64 {
+	return sum(t.rowHeights, from, to)
+}
+
+// 計算：表のレイアウト（セル配置、列幅、行の高さ）
+func (p *PDF) layoutTable(documentConfigure types.DocumentConfigure, decoded types.ElementTable, width float64, path string) (tableLayout, error) {
+	var layout tableLayout
+	var columnCount = len(decoded.Columns)
+
+	// CELLS
+	occupied := map[[2]int]bool{}
+	for r, row := range decoded.Rows {
+		c := 0
+		for i, cell := range row.Cells {
+			for occupied[[2]int{r, c}] {
+				c++
+			}
+			if c >= columnCount {
+				break
+			}
+
+			if cell.ColSpan < 1 {
+				cell.ColSpan = 1
+			}
+			if cell.RowSpan < 1 {
+				cell.RowSpan = 1
+			}
+			if c+cell.ColSpan > columnCount {
+				cell.ColSpan = columnCount - c
+			}
+			if r+cell.RowSpan > len(decoded.Rows) {
+				cell.RowSpan = len(decoded.Rows) - r
+			}
+			if cell.TextSize == 0 {
+				cell.TextSize = decoded.TextSize
+			}
+			if cell.FontFamily == "" {
+				cell.FontFamily = decoded.FontFamily
+			}
+			if cell.FontStyle == "" {
+				cell.FontStyle = decoded.FontStyle
+			}
+			if cell.Color == nil {
+				cell.Color = &decoded.Color
+			}
+			if cell.BackgroundColor == nil {
+				cell.BackgroundColor = decoded.BackgroundColor
+			}
+
+			text, err := p.executeTemplate(cell.Text, fmt.Sprintf("%s.rows[%d].cells[%d]", path, r, i))
+			if err != nil {
+				return layout, err
+			}
+			cell.Text = text
+
+			for y := r; y < r+cell.RowSpan; y++ {
+				for x := c; x < c+cell.ColSpan; x++ {
+					occupied[[2]int{y, x}] = true
+				}
+			}
+			layout.cells = append(layout.cells, tableCell{TableCell: cell, row: r, col: c})
+			c += cell.ColSpan
+		}
+	}
+
+	// COLUMN WIDTH
+	layout.columnWidths = make([]float64, columnCount)
+	naturalWidths := make([]float64, columnCount)
+	for _, cell := range layout.cells {
+		if cell.ColSpan != 1 || !decoded.Columns[cell.col].Type.IsAuto() {
+			continue
+		}
+		for _, paragraph := range splitParagraphs(cell.spans()) {
+			line, err := p.layoutLine(paragraph)
+			if err != nil {
+				return layout, p.fontError(err, path)
+			}
+			if naturalWidths[cell.col] < line.width+decoded.CellPadding.Horizontal() {
+				naturalWidths[cell.col] = line.width + decoded.CellPadding.Horizontal()
+			}
+		}
+	}
+	remainingWidth := width
+	autoCount := 0
+	autoWidth := 0.0
+	for i, column := range decoded.Columns {
+		if column.Type.IsFixed() {
+			layout.columnWidths[i] = column.Width
+		} else if column.Type.IsRatio() {
+			layout.columnWidths[i] = width * column.Ratio
+		} else {
+			autoCount += 1
+			autoWidth += naturalWidths[i]
+			continue
+		}
+		remainingWidth -= layout.columnWidths[i]
+	}
+	if autoCount > 0 && remainingWidth > 0 {
+		for i, column := range decoded.Columns {
+			if !column.Type.IsAuto() {
+				continue
+			}
+			if autoWidth == 0 {
+				layout.columnWidths[i] = remainingWidth / float64(autoCount)
+			} else {
+				layout.columnWidths[i] = remainingWidth * (naturalWidths[i] / autoWidth)
+			}
+		}
+	}
+
+	// ROW HEIGHT
+	layout.rowHeights = make([]float64, len(decoded.Rows))
+	for r, row := range decoded.Rows {
+		layout.rowHeights[r] = row.Height
+	}
+	cellHeights := make([]float64, len(layout.cells))
+	for i := range layout.cells {
+		cell := &layout.cells[i]
+		if _, err := p.setFont(cell.FontFamily, cell.FontStyle, cell.TextSize); err != nil {
+			return layout, p.fontError(err, path)
+		}
+		lines, err := p.wrapText(cell.spans(), sum(layout.columnWidths, cell.col, cell.col+cell.ColSpan)-decoded.CellPadding.Horizontal())
+		if err != nil {
+			return layout, p.fontError(err, path)
+		}
+		cell.lines = lines
+		cellHeights[i] = cell.textHeight() + decoded.CellPadding.Vertical()
+		if cell.RowSpan == 1 && layout.rowHeights[cell.row] < cellHeights[i] {
+			layout.rowHeights[cell.row] = cellHeights[i]
+		}
+	}
+	for i, cell := range layout.cells {
+		if cell.RowSpan == 1 {
+			continue
+		}
+		if spannedHeight := layout.height(cell.row, cell.row+cell.RowSpan); spannedHeight < cellHeights[i] {
+			layout.rowHeights[cell.row+cell.RowSpan-1] += cellHeights[i] - spannedHeight
+		}
+	}
+
+	// ROW GROUP (行結合されている行は改ページで分割しない)
+	for start := 0; start < len(decoded.Rows); {
+		end := start + 1
+		if start == 0 && decoded.HeaderRows > 0 {
+			end = decoded.HeaderRows
+		}
+		for _, cell := range layout.cells {
+			if cell.row >= start && cell.row < end && cell.row+cell.RowSpan > end {
+				end = cell.row + cell.RowSpan
+			}
+		}
+		layout.groups = append(layout.groups, [2]int{start, end})
+		start = end
+	}
+
+	return layout, nil
+}
+
+// 描画：表（改ページ時はヘッダー行を再描画）
+func (p *PDF) drawTable(documentConfigure types.DocumentConfigure, page types.Page, decoded types.ElementTable, layout tableLayout, lineWrapRect *types.Rect, wrapRect *types.Rect, canBreakPage bool, path string) (types.Rect, error) {
+	var x = lineWrapRect.MaxX() + decoded.Margin.Left
+	var y = lineWrapRect.MinY() + decoded.Margin.Top
+	var top = lineWrapRect.MinY()
+	var headerEnd = 0
+	if decoded.HeaderRows > 0 && len(layout.groups) > 0 {
+		headerEnd = layout.groups[0][1]
+	}
+
+	for i, group := range layout.groups {
+		// ヘッダー行だけがページ末尾に残らないように、最初の行と合わせて判定する
+		needHeight := layout.height(group[0], group[1])
+		if group[0] == 0 && headerEnd > 0 && i+1 < len(layout.groups) {
+			needHeight += layout.height(layout.groups[i+1][0], layout.groups[i+1][1])
+		}
+
+		if canBreakPage && y+needHeight+decoded.Margin.Bottom > p.contentRect.MaxY() {
+			// ページの先頭から置いている場合は改ページしても収まらないので、白紙のページを追加しない
+			if !p.isPageTop(y - decoded.Margin.Top) {
+				if err := p.addPage(documentConfigure, page, lineWrapRect, wrapRect); err != nil {
+					return types.Rect{}, err
+				}
+				x = lineWrapRect.MinX() + decoded.Margin.Left
+				y = lineWrapRect.MinY() + decoded.Margin.Top
+				top = lineWrapRect.MinY()
+
+				if group[0] >= headerEnd && headerEnd > 0 {
+					if err := p.drawTableRows(documentConfigure, decoded, layout, 0, headerEnd, x, y); err != nil {
+						return types.Rect{}, err
+					}
+					y += layout.height(0, headerEnd)
+				}
+			}
+
+			if y+needHeight+decoded.Margin.Bottom > p.contentRect.MaxY() {
+				return types.Rect{}, p.overflowError(fmt.Sprintf("%s.rows[%d]", path, group[0]), types.Size{Width: layout.width(), Height: needHeight}, types.Rect{Origin: types.Origin{X: x, Y: y}})
+			}
+		}
+
+		if err := p.drawTableRows(documentConfigure, decoded, layout, group[0], group[1], x, y); err != nil {
+			return types.Rect{}, err
+		}
+		y += layout.height(group[0], group[1])
+	}
+
+	return types.Rect{
+		Origin: types.Origin{X: x - decoded.Margin.Left, Y: top},
+		Size:   types.Size{Width: layout.width() + decoded.Margin.Horizontal(), Height: y + decoded.Margin.Bottom - top},
+	}, nil
+}
+
+// 描画：表の行
+func (p *PDF) drawTableRows(documentConfigure types.DocumentConfigure, decoded types.ElementTable, layout tableLayout, from int, to int, x float64, y float64) error {
+	for _, cell := range layout.cells {
+		if cell.row < from || cell.row >= to {
+			continue
+		}
+		cellFrame := types.Rect{
+			Origin: types.Origin{X: x + sum(layout.columnWidths, 0, cell.col), Y: y + layout.height(from, cell.row)},
+			Size:   types.Size{Width: sum(layout.columnWidths, cell.col, cell.col+cell.ColSpan), Height: layout.height(cell.row, cell.row+cell.RowSpan)},
+		}
+		if err := p.drawTableCell(documentConfigure, decoded, cell, cellFrame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 描画：表のセル
+func (p *PDF) drawTableCell(documentConfigure types.DocumentConfigure, decoded types.ElementTable, cell tableCell, cellFrame types.Rect) error {
+	// FILL
+	if cell.BackgroundColor != nil {
+		if err := p.fillRect(*cell.BackgroundColor, cellFrame); err != nil {
+			return err
+		}
+	}
+
+	// TEXT
+	if _, err := p.setFont(cell.FontFamily, cell.FontStyle, cell.TextSize); err != nil {
+		return err
+	}
+	p.setFillColor(*cell.Color)
+	p.setTextColor(*cell.Color)
+
+	var err error
+	textRect := cellFrame.ApplyMargin(decoded.CellPadding)
+	textY := textRect.MinY()
+	if cell.Valign.IsMiddle() {
+		textY += (textRect.Height() - cell.textHeight()) / 2
+	} else if cell.Valign.IsBottom() {
+		textY += textRect.Height() - cell.textHeight()
+	}
+	for _, line := range cell.lines {
+		if cell.Align.IsJustify() {
+			if line, err = p.justifyLine(line, textRect.Width()); err != nil {
+				return err
+			}
+		}
+		lineRect := types.Rect{Origin: types.Origin{X: textRect.MinX(), Y: textY}, Size: types.Size{Width: textRect.Width(), Height: line.height()}}
+		if err := p.drawLine(line, p.alignLine(line, lineRect, cell.Align), p.baselineLine(line, lineRect, types.ValignTop)); err != nil {
+			return err
+		}
+		textY += lineRect.Height()
+	}
+
+	// BORDER
+	if cell.Border.Width != UnsetWidth {
+		err = p.drawFrameBorder(cell.Border, cellFrame, types.BorderRadius{})
+	} else if cell.BorderTop.Width != UnsetWidth || cell.BorderRight.Width != UnsetWidth || cell.BorderBottom.Width != UnsetWidth || cell.BorderLeft.Width != UnsetWidth {
+		err = p.drawFrameBorders(cell.BorderTop, cell.BorderRight, cell.BorderBottom, cell.BorderLeft, cellFrame)
+	} else if decoded.Border.Width != UnsetWidth {
+		err = p.drawFrameBorder(decoded.Border, cellFrame, types.BorderRadius{})
+	}
+	if err != nil {
+		return err
+	}
+
+	// RESET COLOR
+	p.setStrokeColor(documentConfigure.TextColor)
+	p.setFillColor(documentConfigure.TextColor)
+	p.setTextColor(documentConfigure.TextColor)
+
+	return nil
+}
+
+func sum(values []float64, from int, to int) float64 {
+	var total float64
+	for i := from; i < to && i < len(values); i++ {
+		total += values[i]
+	}
+	return total
+}
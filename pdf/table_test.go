@@ -0,0 +1,118 @@
+package pdf
+
+import (
+	"apple-x-co/go-pdf/types"
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// 表のレイアウト JSON（spacer の高さのテキストの後に、高さ 40 の行の表を置く。先頭の行は見出し）
+func testTableLayout(spacer float64, rows int) string {
+	var tableRows []string
+	tableRows = append(tableRows, `{"height": 40, "cells": [{"text": "HEADER"}]}`)
+	for i := 0; i < rows; i++ {
+		tableRows = append(tableRows, fmt.Sprintf(`{"height": 40, "cells": [{"text": "row %d"}]}`, i))
+	}
+	return fmt.Sprintf(`{
+  "width": 400, "height": 400, "compress_level": 0,
+  "pages": [{"liner_layout": {"orientation": "vertical", "liner_layouts": [
+    {"elements": [{"type": "text", "attributes": {"text": "spacer", "size": {"width": 100, "height": %v}}}]},
+    {"elements": [{"type": "table", "attributes": {"header_rows": 1, "columns": [{"type": "fixed", "width": 200}], "rows": [%s]}}]}
+  ]}}]
+}`, spacer, strings.Join(tableRows, ", "))
+}
+
+var tjPattern = regexp.MustCompile(`\[<[0-9A-F]+>\] TJ`)
+
+func TestTablePageBreak(t *testing.T) {
+	tests := []struct {
+		name    string
+		spacer  float64
+		rows    int
+		pages   int
+		headers int
+	}{
+		// 本文 380 のページに見出し + 8 行（360）が収まる
+		{name: "fits", spacer: 0, rows: 7, pages: 1, headers: 1},
+		{name: "repeats header", spacer: 0, rows: 20, pages: 3, headers: 3},
+		// 前のレイアウトでページが埋まっている場合は見出しと最初の行を次のページに送る
+		{name: "after previous layout", spacer: 350, rows: 2, pages: 2, headers: 1},
+		{name: "header does not stay alone", spacer: 320, rows: 2, pages: 2, headers: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := renderTestLayout(t, testTableLayout(test.spacer, test.rows), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := countPages(b); got != test.pages {
+				t.Errorf("pages = %d, want %d", got, test.pages)
+			}
+
+			// 余白のテキストの次に描く文字列が見出し
+			header := tjPattern.FindAll(b, 2)[1]
+			if got := bytes.Count(b, header); got != test.headers {
+				t.Errorf("headers = %d, want %d", got, test.headers)
+			}
+		})
+	}
+}
+
+func TestTableRowOverflow(t *testing.T) {
+	layout := `{
+  "width": 400, "height": 400,
+  "pages": [{"liner_layout": {"elements": [{"type": "table", "attributes": {
+    "columns": [{"type": "fixed", "width": 100}],
+    "rows": [{"height": 2000, "cells": [{"text": "A"}]}]
+  }}]}}]
+}`
+	_, err := renderTestLayout(t, layout, nil)
+	var overflowError *LayoutOverflowError
+	if !errors.As(err, &overflowError) {
+		t.Fatalf("err = %v, want LayoutOverflowError", err)
+	}
+	// 白紙のページを追加せずに最初のページでエラーにする
+	if overflowError.PageIndex != 0 || overflowError.Path != "pages[0].liner_layout.elements[0].rows[0]" {
+		t.Errorf("err = %v, want page index 0 and rows[0]", err)
+	}
+}
+
+func TestTableRowHeightFallbackFont(t *testing.T) {
+	documentConfigure := NewDocumentConfigure(testFontPath)
+	documentConfigure.Fonts = map[string]types.FontFamily{"mono": {Regular: "testdata/DejaVuSansMono.ttf"}}
+	documentConfigure.FallbackFonts = []string{"mono"}
+	p := newTestFontPDF(t, documentConfigure)
+	primary, fallback := p.fonts[DefaultFontFamily].height/100, p.fonts["mono"].height/100
+
+	tests := []struct {
+		text string
+		want float64
+	}{
+		{text: "A", want: primary},
+		// 主フォントにない文字はフォールバックしたフォントの行の高さにする
+		{text: "✓", want: fallback},
+		{text: "A✓", want: fallback},
+		{text: "A\n✓", want: primary + fallback},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			decoded := types.ElementTable{
+				TextSize: 10,
+				Columns:  []types.TableColumn{{Type: types.TableColumnFixed, Width: 100}},
+				Rows:     []types.TableRow{{Cells: []types.TableCell{{Text: test.text}}}},
+			}
+			layout, err := p.layoutTable(documentConfigure, decoded, 100, "table")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := layout.height(0, 1); math.Abs(got-test.want) > 1e-9 {
+				t.Errorf("row height = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
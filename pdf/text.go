@@ -0,0 +1,489 @@
+package pdf
+
+import (
+	"apple-x-co/go-pdf/types"
+	"bytes"
+	"github.com/signintech/gopdf"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+type templateVars struct {
+	PageNumber        uint
+	TotalPages        uint
+	SectionPageNumber uint
+	SectionTotalPages uint
+	Now               string
+	Data              interface{}
+	Item              interface{}
+	Index             int
+}
+
+// テンプレート展開
+func (p *PDF) executeTemplate(text string, path string) (string, error) {
+	if strings.Contains(text, "TotalPages") {
+		p.usesTotalPages = true
+	}
+
+	vars := templateVars{
+		PageNumber:        p.pageNumber,
+		TotalPages:        p.totalPages,
+		SectionPageNumber: p.pageNumber - p.sectionStart + 1,
+		Now:               p.now.Format("2006-01-02 15:04:05"),
+		Data:              p.data,
+		Item:              p.item,
+		Index:             p.index,
+	}
+	if p.sectionIndex < len(p.sectionTotals) {
+		vars.SectionTotalPages = p.sectionTotals[p.sectionIndex]
+	}
+	tmpl, err := template.New("text").Parse(text)
+	if err != nil {
+		return "", &TemplateParseError{PageIndex: p.pageIndex(), Path: path, Text: text, Err: err}
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", &TemplateParseError{PageIndex: p.pageIndex(), Path: path, Text: text, Err: err}
+	}
+	return buf.String(), nil
+}
+
+// 表示条件：テンプレートの結果が空・false・0・<no value> のときは非表示
+func (p *PDF) isVisible(expression string, path string) (bool, error) {
+	if expression == "" {
+		return true, nil
+	}
+	result, err := p.executeTemplate(expression, path)
+	if err != nil {
+		return false, err
+	}
+	switch strings.TrimSpace(result) {
+	case "", "false", "0", "<no value>":
+		return false, nil
+	}
+	return true, nil
+}
+
+// テキストの書式（spans の要素ごと）
+type textSpan struct {
+	text       string
+	fontFamily string
+	fontStyle  types.FontStyle
+	size       float64
+	spacing    float64
+	style      textStyle
+}
+
+// 描画の書式
+type textStyle struct {
+	color           types.Color
+	backgroundColor *types.Color
+	decoration      types.TextDecoration
+}
+
+// テキストの断片（同じフォント・書式で描画する範囲）
+type textRun struct {
+	text    string
+	font    font
+	size    float64
+	width   float64
+	spacing float64 // 文字ごとに後ろに空ける幅（letter_spacing）
+	style   textStyle
+	gap     float64 // 後ろに空ける幅（両端揃え）
+	base    *textLine
+	ruby    *textLine
+}
+
+// テキストの行
+type textLine struct {
+	runs  []textRun
+	font  font
+	size  float64
+	width float64
+	end   bool // 段落の最終行
+}
+
+// 行の高さ（ベースラインから上）
+func (l textLine) ascent() float64 {
+	ascent := l.font.ascender * l.size / 1000
+	for _, run := range l.runs {
+		a := run.font.ascender * run.size / 1000
+		if run.ruby != nil {
+			a = run.base.ascent() + run.ruby.ascent() - run.ruby.descent()
+		}
+		if ascent < a {
+			ascent = a
+		}
+	}
+	return ascent
+}
+
+// 行の深さ（ベースラインから下、負の値）
+func (l textLine) descent() float64 {
+	descent := l.font.descender * l.size / 1000
+	for _, run := range l.runs {
+		d := run.font.descender * run.size / 1000
+		if run.base != nil {
+			d = run.base.descent()
+		}
+		if descent > d {
+			descent = d
+		}
+	}
+	return descent
+}
+
+// 行の高さ（計測用）
+func (l textLine) height() float64 {
+	height := l.font.height * (l.size / 1000.0)
+	for _, run := range l.runs {
+		if h := run.font.height * (run.size / 1000.0); height < h {
+			height = h
+		}
+	}
+	return height + l.rubyHeight()
+}
+
+// 書式：テキスト要素の spans（指定のない項目は要素の値を使う）
+func (p *PDF) textSpans(decoded types.ElementText) []textSpan {
+	var element = textSpan{
+		text:       decoded.Text,
+		fontFamily: decoded.FontFamily,
+		fontStyle:  decoded.FontStyle,
+		size:       float64(decoded.TextSize),
+		spacing:    decoded.LetterSpacing,
+		style:      textStyle{color: decoded.Color, decoration: decoded.Decoration},
+	}
+	if len(decoded.Spans) == 0 {
+		return []textSpan{element}
+	}
+
+	var spans []textSpan
+	for _, s := range decoded.Spans {
+		span := element
+		span.text = s.Text
+		if s.TextSize != 0 {
+			span.size = float64(s.TextSize)
+		}
+		if s.FontFamily != "" {
+			span.fontFamily = s.FontFamily
+		}
+		if s.FontStyle != "" {
+			span.fontStyle = s.FontStyle
+		}
+		if s.Color != nil {
+			span.style.color = *s.Color
+		}
+		span.style.backgroundColor = s.BackgroundColor
+		if s.Underline && !span.style.decoration.Underline.IsDouble() {
+			span.style.decoration.Underline = types.UnderlineSingle
+		}
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+// 分割：改行で段落に分ける（段落には必ず1つ以上の書式がある）
+func splitParagraphs(spans []textSpan) [][]textSpan {
+	var paragraphs = [][]textSpan{nil}
+	for _, span := range spans {
+		for i, text := range strings.Split(span.text, "\n") {
+			if i > 0 {
+				paragraphs = append(paragraphs, nil)
+			}
+			paragraph := span
+			paragraph.text = text
+			paragraphs[len(paragraphs)-1] = append(paragraphs[len(paragraphs)-1], paragraph)
+		}
+	}
+	return paragraphs
+}
+
+// 分割：ルビと、フォント・書式ごとの断片にする
+func (p *PDF) layoutLine(spans []textSpan) (textLine, error) {
+	primary, err := p.resolveFont(spans[0].fontFamily, spans[0].fontStyle)
+	if err != nil {
+		return textLine{}, err
+	}
+
+	var line = textLine{font: primary, size: spans[0].size}
+	for _, span := range spans {
+		f, err := p.resolveFont(span.fontFamily, span.fontStyle)
+		if err != nil {
+			return line, err
+		}
+		var last = 0
+		for _, match := range rubyPattern.FindAllStringSubmatchIndex(span.text, -1) {
+			if err := p.appendRuns(&line, span.text[last:match[0]], f, span); err != nil {
+				return line, err
+			}
+			run, err := p.layoutRuby(span.text[match[2]:match[3]], span.text[match[4]:match[5]], span)
+			if err != nil {
+				return line, err
+			}
+			line.runs = append(line.runs, run)
+			line.width += run.width
+			last = match[1]
+		}
+		if err := p.appendRuns(&line, span.text[last:], f, span); err != nil {
+			return line, err
+		}
+	}
+	if len(line.runs) == 0 {
+		line.runs = []textRun{{font: primary, size: line.size, style: spans[0].style}}
+	}
+
+	return line, p.useFont(primary, line.size)
+}
+
+// 分割：主フォントにない文字はフォールバックフォントの断片にする
+func (p *PDF) appendRuns(line *textLine, text string, f font, span textSpan) error {
+	if text == "" {
+		return nil
+	}
+
+	var runs []textRun
+	if len(p.fallbackFonts) > 0 {
+		for _, r := range text {
+			glyphFont, err := p.resolveGlyphFont(f, span.fontStyle, r)
+			if err != nil {
+				return err
+			}
+			if n := len(runs); n > 0 && runs[n-1].font.name == glyphFont.name {
+				runs[n-1].text += string(r)
+			} else {
+				runs = append(runs, textRun{text: string(r), font: glyphFont, size: span.size, spacing: span.spacing, style: span.style})
+			}
+		}
+	} else {
+		runs = []textRun{{text: text, font: f, size: span.size, spacing: span.spacing, style: span.style}}
+	}
+
+	for _, run := range runs {
+		if err := p.useFont(run.font, run.size); err != nil {
+			return err
+		}
+		width, err := p.gp.MeasureTextWidth(run.text)
+		if err != nil {
+			return err
+		}
+		run.width = width + run.spacing*float64(utf8.RuneCountInString(run.text))
+		line.runs = append(line.runs, run)
+		line.width += run.width
+	}
+	return nil
+}
+
+// 文字（折り返し位置の計算用、width は letter_spacing を含む）
+type textGlyph struct {
+	r       rune
+	font    font
+	size    float64
+	width   float64
+	spacing float64
+	style   textStyle
+	gap     float64
+	base    *textLine
+	ruby    *textLine
+}
+
+// 分割：文字ごとのフォントと幅
+func (p *PDF) layoutGlyphs(spans []textSpan) ([]textGlyph, error) {
+	line, err := p.layoutLine(spans)
+	if err != nil {
+		return nil, err
+	}
+	return p.lineGlyphs(line)
+}
+
+// 分割：行を文字にする（ルビの付いた文字列は分割しない）
+func (p *PDF) lineGlyphs(line textLine) ([]textGlyph, error) {
+	var glyphs []textGlyph
+	for _, run := range line.runs {
+		if run.ruby != nil {
+			r, _ := utf8.DecodeRuneInString(run.text)
+			glyphs = append(glyphs, textGlyph{r: r, font: run.font, size: run.size, width: run.width, style: run.style, gap: run.gap, base: run.base, ruby: run.ruby})
+			continue
+		}
+		if err := p.useFont(run.font, run.size); err != nil {
+			return nil, err
+		}
+		for _, r := range run.text {
+			width, err := p.gp.MeasureTextWidth(string(r))
+			if err != nil {
+				return nil, err
+			}
+			glyphs = append(glyphs, textGlyph{r: r, font: run.font, size: run.size, width: width + run.spacing, spacing: run.spacing, style: run.style})
+		}
+		if run.gap != 0 {
+			glyphs[len(glyphs)-1].gap = run.gap
+		}
+	}
+	return glyphs, p.useFont(line.font, line.size)
+}
+
+// 折り返し：禁則処理をして幅に収まるように行を分割（1文字も収まらない場合も1文字は置く）
+func (p *PDF) wrapText(spans []textSpan, width float64) ([]textLine, error) {
+	var lines []textLine
+	for _, paragraph := range splitParagraphs(spans) {
+		primary, err := p.resolveFont(paragraph[0].fontFamily, paragraph[0].fontStyle)
+		if err != nil {
+			return nil, err
+		}
+		glyphs, err := p.layoutGlyphs(paragraph)
+		if err != nil {
+			return nil, err
+		}
+		if len(glyphs) == 0 {
+			lines = append(lines, textLine{font: primary, size: paragraph[0].size, end: true})
+			continue
+		}
+		for start := 0; start < len(glyphs); {
+			end, next := nextLineBreak(glyphs, start, width)
+			lines = append(lines, newTextLine(glyphs[start:end], primary, paragraph[0].size))
+			start = next
+		}
+		lines[len(lines)-1].end = true
+	}
+	return lines, nil
+}
+
+// 行：同じフォント・書式の文字をまとめる（後ろに幅を空ける文字で区切る）
+func newTextLine(glyphs []textGlyph, primary font, size float64) textLine {
+	var line = textLine{font: primary, size: size}
+	for _, glyph := range glyphs {
+		if glyph.ruby != nil {
+			line.runs = append(line.runs, textRun{text: glyph.base.text(), font: glyph.font, size: glyph.size, width: glyph.width, style: glyph.style, gap: glyph.gap, base: glyph.base, ruby: glyph.ruby})
+		} else if n := len(line.runs); n > 0 && line.runs[n-1].ruby == nil && line.runs[n-1].gap == 0 && line.runs[n-1].font.name == glyph.font.name && line.runs[n-1].size == glyph.size && line.runs[n-1].spacing == glyph.spacing && line.runs[n-1].style == glyph.style {
+			line.runs[n-1].text += string(glyph.r)
+			line.runs[n-1].width += glyph.width
+			line.runs[n-1].gap = glyph.gap
+		} else {
+			line.runs = append(line.runs, textRun{text: string(glyph.r), font: glyph.font, size: glyph.size, width: glyph.width, spacing: glyph.spacing, style: glyph.style, gap: glyph.gap})
+		}
+		line.width += glyph.width + glyph.gap
+	}
+	return line
+}
+
+// 描画：行（baseline はベースラインのY座標）
+func (p *PDF) drawLine(line textLine, x float64, baseline float64) error {
+	for _, run := range line.runs {
+		// BACKGROUND
+		if run.style.backgroundColor != nil {
+			backgroundRect := types.Rect{Origin: types.Origin{X: x, Y: baseline - line.ascent()}, Size: types.Size{Width: run.width + run.gap, Height: line.ascent() - line.descent()}}
+			if err := p.fillRect(*run.style.backgroundColor, backgroundRect); err != nil {
+				return err
+			}
+		}
+
+		// ルビ：親文字の上に中央揃えで置く
+		if run.ruby != nil {
+			if err := p.drawLine(*run.base, x+(run.width-run.base.width)*0.5, baseline); err != nil {
+				return err
+			}
+			if err := p.drawLine(*run.ruby, x+(run.width-run.ruby.width)*0.5, baseline-run.base.ascent()+run.ruby.descent()); err != nil {
+				return err
+			}
+			x += run.width + run.gap
+			continue
+		}
+
+		if err := p.useFont(run.font, run.size); err != nil {
+			return err
+		}
+		p.setTextColor(run.style.color)
+		if err := p.drawWithAlpha(run.style.color.Alpha(), func() error {
+			if run.spacing == 0 {
+				return p.drawCell(run.text, x, baseline-run.font.ascender*run.size/1000, run.width, line.ascent()-line.descent())
+			}
+			// 字間：1文字ずつ置く
+			cellX := x
+			for _, r := range run.text {
+				width, err := p.gp.MeasureTextWidth(string(r))
+				if err != nil {
+					return err
+				}
+				if err := p.drawCell(string(r), cellX, baseline-run.font.ascender*run.size/1000, width, line.ascent()-line.descent()); err != nil {
+					return err
+				}
+				cellX += width + run.spacing
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		// DECORATION
+		if err := p.drawDecoration(run, x, baseline); err != nil {
+			return err
+		}
+
+		x += run.width + run.gap
+	}
+	return p.useFont(line.font, line.size)
+}
+
+// 描画：下線・取り消し線（色の指定がない場合は文字色、太さの指定がない場合はフォントの下線の太さ）
+func (p *PDF) drawDecoration(run textRun, x float64, baseline float64) error {
+	decoration := run.style.decoration
+	if decoration.IsZero() {
+		return nil
+	}
+
+	thickness := decoration.Thickness
+	if thickness == 0 {
+		thickness = run.font.underlineThickness * run.size / 1000
+	}
+	color := run.style.color
+	if decoration.Color != nil {
+		color = *decoration.Color
+	}
+
+	return p.drawWithAlpha(color.Alpha(), func() error {
+		p.gp.SetLineWidth(thickness)
+		p.setStrokeColor(color)
+
+		width := run.width + run.gap
+		if decoration.Underline.IsSingle() || decoration.Underline.IsDouble() {
+			y := baseline - run.font.underlinePosition*run.size/1000
+			p.gp.Line(x, y, x+width, y)
+			if decoration.Underline.IsDouble() {
+				p.gp.Line(x, y+thickness*2, x+width, y+thickness*2)
+			}
+		}
+		if decoration.Strikethrough {
+			y := baseline - run.font.strikeoutPosition*run.size/1000
+			p.gp.Line(x, y, x+width, y)
+		}
+		return nil
+	})
+}
+
+// 描画：文字列（x, y は左上）
+func (p *PDF) drawCell(text string, x float64, y float64, width float64, height float64) error {
+	p.gp.SetX(x)
+	p.gp.SetY(y)
+	return p.gp.CellWithOption(&gopdf.Rect{W: width, H: height}, text, gopdf.CellOption{Align: gopdf.Left | gopdf.Top, Float: gopdf.Right})
+}
+
+// 計算：行の描画位置（X座標）
+func (p *PDF) alignLine(line textLine, rect types.Rect, align types.Align) float64 {
+	if align.IsCenter() {
+		return rect.MinX() + rect.Width()*0.5 - line.width*0.5
+	} else if align.IsRight() {
+		return rect.MinX() + rect.Width() - line.width
+	}
+	return rect.MinX()
+}
+
+// 計算：行のベースライン（Y座標）
+func (p *PDF) baselineLine(line textLine, rect types.Rect, valign types.Valign) float64 {
+	if valign.IsMiddle() {
+		return rect.MinY() + rect.Height()*0.5 + (line.descent()+line.ascent())*0.5
+	} else if valign.IsBottom() {
+		return rect.MinY() + rect.Height() + line.descent()
+	}
+	return rect.MinY() + line.ascent()
+}
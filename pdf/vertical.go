@@ -0,0 +1,271 @@
+package pdf
+
+import (
+	"apple-x-co/go-pdf/types"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// 縦書きで横倒しにする和文の文字（長音・括弧・ダッシュなど）
+const verticalSideways = "ー－～〜‐―（）［］｛｝「」『』【】〔〕〈〉《》〘〙〖〗｟｠＜＞＝：；"
+
+// 縦書きで右上に寄せる句読点
+const verticalPunctuation = "、。，．"
+
+// 縦書きで句読点を寄せる量（文字サイズに対する割合）
+const verticalPunctuationOffset = 0.6
+
+// 縦書きの列
+type textColumn struct {
+	glyphs []textGlyph
+	height float64
+	ruby   float64
+	end    bool // 段落の最後の列
+}
+
+// 判定：縦書きで横倒しにする（欧文・数字は横倒し）
+func isSideways(r rune) bool {
+	if strings.ContainsRune(verticalSideways, r) {
+		return true
+	}
+	return !isCJK(r)
+}
+
+// 縦書きの送り幅（ルビの付いた文字列は親文字とルビの長い方）
+func (p *PDF) verticalAdvance(glyph textGlyph) (float64, error) {
+	if glyph.ruby != nil {
+		baseHeight, err := p.verticalHeight(*glyph.base)
+		if err != nil {
+			return 0, err
+		}
+		rubyHeight, err := p.verticalHeight(*glyph.ruby)
+		if err != nil {
+			return 0, err
+		}
+		return math.Max(baseHeight, rubyHeight), nil
+	}
+	if isSideways(glyph.r) {
+		return glyph.width, nil
+	}
+	return glyph.size + glyph.spacing, nil
+}
+
+// 縦書きの行の長さ
+func (p *PDF) verticalHeight(line textLine) (float64, error) {
+	glyphs, err := p.lineGlyphs(line)
+	if err != nil {
+		return 0, err
+	}
+	var height float64
+	for _, glyph := range glyphs {
+		advance, err := p.verticalAdvance(glyph)
+		if err != nil {
+			return 0, err
+		}
+		height += advance
+	}
+	return height, nil
+}
+
+// 分割：縦書きの列（height が 0 の場合は折り返さない）
+func (p *PDF) layoutColumns(spans []textSpan, height float64) ([]textColumn, error) {
+	var columns []textColumn
+	for _, paragraph := range splitParagraphs(spans) {
+		glyphs, err := p.layoutGlyphs(paragraph)
+		if err != nil {
+			return nil, err
+		}
+
+		// 送り幅で禁則処理をして折り返す
+		advances := make([]textGlyph, len(glyphs))
+		for i, glyph := range glyphs {
+			advance, err := p.verticalAdvance(glyph)
+			if err != nil {
+				return nil, err
+			}
+			advances[i] = textGlyph{r: glyph.r, width: advance}
+		}
+		if height == UnsetHeight || len(glyphs) == 0 {
+			columns = append(columns, newTextColumn(glyphs, advances))
+		} else {
+			for start := 0; start < len(glyphs); {
+				end, next := nextLineBreak(advances, start, height)
+				columns = append(columns, newTextColumn(glyphs[start:end], advances[start:end]))
+				start = next
+			}
+		}
+		columns[len(columns)-1].end = true
+	}
+	return columns, nil
+}
+
+func newTextColumn(glyphs []textGlyph, advances []textGlyph) textColumn {
+	var column = textColumn{glyphs: glyphs}
+	for i, advance := range advances {
+		column.height += advance.width
+		if glyphs[i].ruby != nil && column.ruby < glyphs[i].ruby.size {
+			column.ruby = glyphs[i].ruby.size
+		}
+	}
+	return column
+}
+
+// 計算：縦書きテキストのサイズ
+func (p *PDF) measureVerticalText(decoded types.ElementText) (types.Size, error) {
+	f, err := p.setFont(decoded.FontFamily, decoded.FontStyle, decoded.TextSize)
+	if err != nil {
+		return types.Size{}, err
+	}
+
+	var height = UnsetHeight
+	if decoded.Wrap {
+		height = decoded.Size.Height
+	}
+	columns, err := p.layoutColumns(p.textSpans(decoded), height)
+	if err != nil {
+		return types.Size{}, err
+	}
+
+	lineHeight := decoded.LineHeight.Apply(f.height * (float64(decoded.TextSize) / 1000.0))
+	measureSize := types.Size{}
+	for i, column := range columns {
+		if i > 0 && columns[i-1].end {
+			measureSize.Width += decoded.ParagraphSpacing
+		}
+		measureSize.Width += lineHeight + column.ruby
+		if measureSize.Height < column.height {
+			measureSize.Height = column.height
+		}
+	}
+
+	if decoded.Size.Width != UnsetWidth {
+		measureSize.Width = decoded.Size.Width
+	}
+	if decoded.Size.Height != UnsetHeight {
+		measureSize.Height = decoded.Size.Height
+	}
+
+	return measureSize, nil
+}
+
+// 描画：縦書きテキスト（列は右から左へ並べ、ルビは列の右側に置く）
+func (p *PDF) drawVerticalText(decoded types.ElementText, textRect types.Rect) error {
+	f, err := p.setFont(decoded.FontFamily, decoded.FontStyle, decoded.TextSize)
+	if err != nil {
+		return err
+	}
+
+	var height = UnsetHeight
+	if decoded.Wrap {
+		height = textRect.Height()
+	}
+	columns, err := p.layoutColumns(p.textSpans(decoded), height)
+	if err != nil {
+		return err
+	}
+
+	lineHeight := decoded.LineHeight.Apply(f.height * (float64(decoded.TextSize) / 1000.0))
+	var blockWidth float64
+	for i, column := range columns {
+		if i > 0 && columns[i-1].end {
+			blockWidth += decoded.ParagraphSpacing
+		}
+		blockWidth += lineHeight + column.ruby
+	}
+	right := textRect.MaxX()
+	if decoded.Align.IsCenter() {
+		right = textRect.MinX() + textRect.Width()*0.5 + blockWidth*0.5
+	} else if decoded.Align == types.AlignLeft {
+		right = textRect.MinX() + blockWidth
+	}
+
+	for i, column := range columns {
+		if i > 0 && columns[i-1].end {
+			right -= decoded.ParagraphSpacing
+		}
+		centerX := right - column.ruby - lineHeight*0.5
+		y := textRect.MinY()
+		if decoded.Valign.IsMiddle() {
+			y += (textRect.Height() - column.height) * 0.5
+		} else if decoded.Valign.IsBottom() {
+			y += textRect.Height() - column.height
+		}
+		if err := p.drawVerticalGlyphs(column.glyphs, centerX, y); err != nil {
+			return err
+		}
+		right -= lineHeight + column.ruby
+	}
+
+	return nil
+}
+
+// 描画：縦書きの文字（centerX は列の中央、y は先頭のY座標）
+func (p *PDF) drawVerticalGlyphs(glyphs []textGlyph, centerX float64, y float64) error {
+	for i := 0; i < len(glyphs); {
+		glyph := glyphs[i]
+
+		// ルビ：親文字の右に中央揃えで置く
+		if glyph.ruby != nil {
+			advance, err := p.verticalAdvance(glyph)
+			if err != nil {
+				return err
+			}
+			for _, line := range []*textLine{glyph.base, glyph.ruby} {
+				lineGlyphs, err := p.lineGlyphs(*line)
+				if err != nil {
+					return err
+				}
+				lineHeight, err := p.verticalHeight(*line)
+				if err != nil {
+					return err
+				}
+				lineCenterX := centerX
+				if line == glyph.ruby {
+					lineCenterX += (glyph.size + line.size) * 0.5
+				}
+				if err := p.drawVerticalGlyphs(lineGlyphs, lineCenterX, y+(advance-lineHeight)*0.5); err != nil {
+					return err
+				}
+			}
+			y += advance
+			i++
+			continue
+		}
+
+		// 横倒し：同じフォントで続く文字をまとめて90度回転する
+		if isSideways(glyph.r) {
+			j := i + 1
+			for j < len(glyphs) && glyphs[j].ruby == nil && isSideways(glyphs[j].r) && glyphs[j].font.name == glyph.font.name {
+				j++
+			}
+			line := newTextLine(glyphs[i:j], glyph.font, glyph.size)
+			p.gp.Rotate(-90, centerX, y)
+			err := p.drawLine(line, centerX, y+(line.ascent()+line.descent())*0.5)
+			p.gp.RotateReset()
+			if err != nil {
+				return err
+			}
+			y += line.width
+			i = j
+			continue
+		}
+
+		// 正立：列の中央に置く
+		line := newTextLine(glyphs[i:i+1], glyph.font, glyph.size)
+		x := centerX - (glyph.width-glyph.spacing)*0.5
+		baseline := y + glyph.size*glyph.font.ascender/(glyph.font.ascender-glyph.font.descender)
+		if strings.ContainsRune(verticalPunctuation, glyph.r) {
+			x += glyph.size * verticalPunctuationOffset
+			baseline -= glyph.size * verticalPunctuationOffset
+		}
+		if !unicode.IsSpace(glyph.r) {
+			if err := p.drawLine(line, x, baseline); err != nil {
+				return err
+			}
+		}
+		y += glyph.size + glyph.spacing
+		i++
+	}
+	return nil
+}
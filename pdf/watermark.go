@@ -0,0 +1,77 @@
+package pdf
+
+import (
+	"apple-x-co/go-pdf/types"
+)
+
+// 描画：透かし（front が true の場合は前面、false の場合は背面の透かし）
+func (p *PDF) drawWatermark(documentConfigure types.DocumentConfigure, front bool) error {
+	watermark := documentConfigure.Watermark
+	if watermark.IsZero() || watermark.Layer.IsFront() != front {
+		return nil
+	}
+	if visible, err := p.isVisible(watermark.VisibleIf, "watermark.visible_if"); err != nil || !visible {
+		return err
+	}
+
+	// 余白を除いたページに position で揃える
+	pageRect := types.Rect{
+		Origin: types.Origin{X: p.gp.MarginLeft(), Y: p.gp.MarginTop()},
+		Size:   types.Size{Width: documentConfigure.Width - p.gp.MarginLeft() - p.gp.MarginRight(), Height: documentConfigure.Height - p.gp.MarginTop() - p.gp.MarginBottom()},
+	}
+	placeFrame := func(size types.Size) types.Rect {
+		point := watermark.Position.Point(pageRect)
+		offset := watermark.Position.Point(types.Rect{Size: size})
+		return types.Rect{Origin: types.Origin{X: point.X - offset.X, Y: point.Y - offset.Y}, Size: size}
+	}
+
+	// IMAGE
+	if watermark.Path != "" {
+		decoded := types.ElementImage{Path: watermark.Path, Size: watermark.Size, Resolution: DefaultImageResolution}
+		measureSize, err := p.measureImage(documentConfigure, decoded)
+		if err != nil {
+			return p.imageError(err, "watermark")
+		}
+		imageFrame := placeFrame(measureSize)
+		if err := p.drawWatermarkWithOpacity(watermark, imageFrame, func() error {
+			return p.drawImage(documentConfigure, decoded, imageFrame, imageFrame)
+		}); err != nil {
+			return p.imageError(err, "watermark")
+		}
+		return nil
+	}
+
+	// TEXT
+	text, err := p.executeTemplate(watermark.Text, "watermark.text")
+	if err != nil {
+		return err
+	}
+	decoded := types.ElementText{
+		Text:       text,
+		TextSize:   watermark.TextSize,
+		FontFamily: watermark.FontFamily,
+		FontStyle:  watermark.FontStyle,
+		Color:      watermark.Color,
+	}
+	if decoded.TextSize == 0 {
+		decoded.TextSize = documentConfigure.TextSize
+	}
+	measureSize, err := p.measureText(documentConfigure, decoded)
+	if err != nil {
+		return p.fontError(err, "watermark")
+	}
+	textFrame := placeFrame(measureSize)
+	if err := p.drawWatermarkWithOpacity(watermark, textFrame, func() error {
+		return p.drawText(documentConfigure, decoded, textFrame, textFrame)
+	}); err != nil {
+		return p.fontError(err, "watermark")
+	}
+	return nil
+}
+
+// 描画：透かしの回転と不透明度
+func (p *PDF) drawWatermarkWithOpacity(watermark types.Watermark, frame types.Rect, draw func() error) error {
+	return p.drawWithAlpha(watermark.Alpha(), func() error {
+		return p.drawRotated(watermark.Rotation, types.Anchor(types.AnchorCenter).Point(frame), draw)
+	})
+}
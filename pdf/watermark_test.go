@@ -0,0 +1,109 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpacity(t *testing.T) {
+	imagePath := filepath.Join(t.TempDir(), "image.png")
+	f, err := os.Create(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		opacity string
+		want    string
+	}{
+		{name: "unset", opacity: "", want: ""},
+		{name: "zero", opacity: `, "opacity": 0`, want: "/ca 0.000"},
+		{name: "half", opacity: `, "opacity": 0.5`, want: "/ca 0.500"},
+	}
+	for _, test := range tests {
+		for _, target := range []string{"image", "watermark"} {
+			t.Run(test.name+" "+target, func(t *testing.T) {
+				var imageOpacity, watermarkOpacity string
+				if target == "image" {
+					imageOpacity = test.opacity
+				} else {
+					watermarkOpacity = test.opacity
+				}
+				b, err := renderTestLayout(t, fmt.Sprintf(`{
+  "width": 400, "height": 400, "compress_level": 0,
+  "watermark": {"text": "DRAFT"%s},
+  "pages": [{"liner_layout": {"elements": [
+    {"type": "image", "attributes": {"path": %q, "size": {"width": 40, "height": 40}%s}}
+  ]}}]
+}`, watermarkOpacity, imagePath, imageOpacity), nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if test.want == "" {
+					if bytes.Contains(b, []byte("/ca ")) {
+						t.Errorf("output has transparency, want opaque")
+					}
+				} else if !bytes.Contains(b, []byte(test.want)) {
+					t.Errorf("output does not contain %q", test.want)
+				}
+			})
+		}
+	}
+}
+
+func TestWatermarkPages(t *testing.T) {
+	tests := []struct {
+		name      string
+		watermark string
+		count     int
+		front     bool
+	}{
+		// 自動改ページで追加したページにも描画する
+		{name: "all pages", watermark: `{"text": "DRAFT"}`, count: 3},
+		{name: "visible_if", watermark: `{"text": "DRAFT", "visible_if": "{{gt .PageNumber 1}}"}`, count: 2},
+		{name: "front", watermark: `{"text": "DRAFT", "layer": "front"}`, count: 3, front: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := renderTestLayout(t, fmt.Sprintf(`{
+  "width": 400, "height": 400, "compress_level": 0,
+  "watermark": %s,
+  "pages": [
+    {"liner_layout": {"elements": [{"type": "text", "attributes": {"text": "Body"}}]}},
+    {"liner_layout": {"orientation": "vertical", "elements": [
+      {"type": "text", "attributes": {"text": "spacer", "size": {"width": 100, "height": 300}}},
+      {"type": "text", "attributes": {"text": "next", "size": {"width": 100, "height": 100}}}
+    ]}}
+  ]
+}`, test.watermark), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := countPages(b); got != 3 {
+				t.Fatalf("pages = %d, want 3", got)
+			}
+			watermark := textTJ(t, testFontPath, "DRAFT")
+			if got := bytes.Count(b, watermark); got != test.count {
+				t.Errorf("watermarks = %d, want %d", got, test.count)
+			}
+			if test.count == 3 {
+				body := bytes.Index(b, textTJ(t, testFontPath, "Body"))
+				if got := bytes.Index(b, watermark) > body; got != test.front {
+					t.Errorf("watermark drawn after body = %v, want %v", got, test.front)
+				}
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package types
 const AlignLeft = "left"
 const AlignCenter = "center"
 const AlignRight = "right"
+const AlignJustify = "justify"
 
 type Align string
 
@@ -15,3 +16,6 @@ func (A Align) IsCenter() bool {
 func (A Align) IsRight() bool {
 	return A == AlignRight
 }
+func (A Align) IsJustify() bool {
+	return A == AlignJustify
+}
@@ -0,0 +1,31 @@
+package types
+
+const AnchorCenter = "center"
+const AnchorTopLeft = "top_left"
+const AnchorTop = "top"
+const AnchorTopRight = "top_right"
+const AnchorLeft = "left"
+const AnchorRight = "right"
+const AnchorBottomLeft = "bottom_left"
+const AnchorBottom = "bottom"
+const AnchorBottomRight = "bottom_right"
+
+type Anchor string
+
+// 座標：矩形のアンカーの位置（未指定は中央）
+func (A Anchor) Point(rect Rect) Origin {
+	var point = Origin{X: rect.MinX() + rect.Width()*0.5, Y: rect.MinY() + rect.Height()*0.5}
+	switch A {
+	case AnchorTopLeft, AnchorLeft, AnchorBottomLeft:
+		point.X = rect.MinX()
+	case AnchorTopRight, AnchorRight, AnchorBottomRight:
+		point.X = rect.MaxX()
+	}
+	switch A {
+	case AnchorTopLeft, AnchorTop, AnchorTopRight:
+		point.Y = rect.MinY()
+	case AnchorBottomLeft, AnchorBottom, AnchorBottomRight:
+		point.Y = rect.MaxY()
+	}
+	return point
+}
@@ -1,6 +1,37 @@
 package types
 
 type Border struct {
-	Width float64 `json:"width"`
-	Color Color   `json:"color"`
+	Width    float64     `json:"width"`
+	Color    Color       `json:"color"`
+	Style    BorderStyle `json:"style"`
+	Dash     []float64   `json:"dash"`
+	LineCap  LineCap     `json:"line_cap"`
+	LineJoin LineJoin    `json:"line_join"`
+}
+
+// 実線で線端・角の指定もない（PDF の線の描画そのまま）
+func (B Border) IsPlain() bool {
+	return B.Style.IsSolid() && len(B.Dash) == 0 && B.LineCap.IsButt() && B.LineJoin.IsMiter()
+}
+
+// 破線のパターン（線の長さ・間隔の繰り返し、実線の場合は nil。二重線に dash を指定すると 2 本とも破線にする）
+func (B Border) DashPattern() []float64 {
+	if len(B.Dash) > 0 {
+		return B.Dash
+	}
+	if B.Style.IsDashed() {
+		return []float64{B.Width * 3, B.Width * 2}
+	}
+	if B.Style.IsDotted() {
+		return []float64{0, B.Width * 2}
+	}
+	return nil
+}
+
+// 線端の形（点線は長さ 0 の線を並べるため、butt の場合も丸にする）
+func (B Border) Cap() LineCap {
+	if B.Style.IsDotted() && B.LineCap.IsButt() {
+		return LineCapRound
+	}
+	return B.LineCap
 }
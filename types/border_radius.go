@@ -0,0 +1,88 @@
+package types
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// 角丸の半径：数値は四隅共通、オブジェクトは角ごと（例：4, {"top_left": 8, "bottom_right": 8}）
+type BorderRadius struct {
+	TopLeft     float64 `json:"top_left"`
+	TopRight    float64 `json:"top_right"`
+	BottomRight float64 `json:"bottom_right"`
+	BottomLeft  float64 `json:"bottom_left"`
+}
+
+func (B *BorderRadius) UnmarshalJSON(data []byte) error {
+	var number float64
+	if err := json.Unmarshal(data, &number); err == nil {
+		*B = BorderRadius{TopLeft: number, TopRight: number, BottomRight: number, BottomLeft: number}
+		return nil
+	}
+
+	type borderRadius BorderRadius
+	return json.Unmarshal(data, (*borderRadius)(B))
+}
+
+func (B BorderRadius) IsZero() bool {
+	return B.TopLeft <= 0 && B.TopRight <= 0 && B.BottomRight <= 0 && B.BottomLeft <= 0
+}
+
+// 適用：枠の大きさに収まるように半径を縮める（隣り合う角の半径の和が辺の長さを超えないようにする）
+func (B BorderRadius) Fit(size Size) BorderRadius {
+	radius := BorderRadius{
+		TopLeft:     math.Max(B.TopLeft, 0),
+		TopRight:    math.Max(B.TopRight, 0),
+		BottomRight: math.Max(B.BottomRight, 0),
+		BottomLeft:  math.Max(B.BottomLeft, 0),
+	}
+	scale := 1.0
+	for _, side := range []struct {
+		length float64
+		sum    float64
+	}{
+		{size.Width, radius.TopLeft + radius.TopRight},
+		{size.Height, radius.TopRight + radius.BottomRight},
+		{size.Width, radius.BottomRight + radius.BottomLeft},
+		{size.Height, radius.BottomLeft + radius.TopLeft},
+	} {
+		if side.sum > 0 && side.length < side.sum*scale {
+			scale = math.Max(side.length, 0) / side.sum
+		}
+	}
+	return BorderRadius{
+		TopLeft:     radius.TopLeft * scale,
+		TopRight:    radius.TopRight * scale,
+		BottomRight: radius.BottomRight * scale,
+		BottomLeft:  radius.BottomLeft * scale,
+	}
+}
+
+// 適用：半径を広げる・縮める（角丸でない角はそのまま）
+func (B BorderRadius) Expand(amount float64) BorderRadius {
+	expand := func(value float64) float64 {
+		if value <= 0 {
+			return 0
+		}
+		return math.Max(value+amount, 0)
+	}
+	return BorderRadius{
+		TopLeft:     expand(B.TopLeft),
+		TopRight:    expand(B.TopRight),
+		BottomRight: expand(B.BottomRight),
+		BottomLeft:  expand(B.BottomLeft),
+	}
+}
+
+// 適用：余白の内側の角丸の半径（角に接する余白の大きい方だけ半径を縮める）
+func (B BorderRadius) Inset(margin Margin) BorderRadius {
+	inset := func(value float64, a float64, b float64) float64 {
+		return math.Max(value-math.Max(a, b), 0)
+	}
+	return BorderRadius{
+		TopLeft:     inset(B.TopLeft, margin.Top, margin.Left),
+		TopRight:    inset(B.TopRight, margin.Top, margin.Right),
+		BottomRight: inset(B.BottomRight, margin.Bottom, margin.Right),
+		BottomLeft:  inset(B.BottomLeft, margin.Bottom, margin.Left),
+	}
+}
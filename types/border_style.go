@@ -0,0 +1,21 @@
+package types
+
+const BorderStyleSolid = "solid"
+const BorderStyleDashed = "dashed"
+const BorderStyleDotted = "dotted"
+const BorderStyleDouble = "double"
+
+type BorderStyle string
+
+func (B BorderStyle) IsSolid() bool {
+	return B == BorderStyleSolid || B == ""
+}
+func (B BorderStyle) IsDashed() bool {
+	return B == BorderStyleDashed
+}
+func (B BorderStyle) IsDotted() bool {
+	return B == BorderStyleDotted
+}
+func (B BorderStyle) IsDouble() bool {
+	return B == BorderStyleDouble
+}
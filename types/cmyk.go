@@ -0,0 +1,9 @@
+package types
+
+// CMYK：シアン・マゼンタ・イエロー・ブラックの割合（0〜100）
+type CMYK struct {
+	C uint8 `json:"c"`
+	M uint8 `json:"m"`
+	Y uint8 `json:"y"`
+	K uint8 `json:"k"`
+}
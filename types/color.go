@@ -1,7 +1,205 @@
 package types
 
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 色：{"r":..,"g":..,"b":..,"a":..} のほか "#RRGGBB" / "#RRGGBBAA" / "rgb()" / "rgba()" / "cmyk()" / CSS の色名 / パレットの名前で指定できる
 type Color struct {
-	R uint8 `json:"r"`
-	G uint8 `json:"g"`
-	B uint8 `json:"b"`
+	R          uint8    `json:"r"`
+	G          uint8    `json:"g"`
+	B          uint8    `json:"b"`
+	A          *float64 `json:"a"`
+	CMYK       *CMYK    `json:"cmyk"`
+	Name       string   `json:"-"` // 名前で指定した場合の名前（パレットの色で置き換える）
+	unresolved bool     // CSS の色名ではなく、パレットの色で置き換えるまで色が決まらない
+}
+
+func (C *Color) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		type color Color
+		return json.Unmarshal(data, (*color)(C))
+	}
+
+	name := strings.TrimSpace(text)
+	if name == "" || strings.HasPrefix(name, "#") || strings.Contains(name, "(") {
+		color, err := ParseColor(text)
+		if err != nil {
+			return err
+		}
+		*C = color
+		return nil
+	}
+
+	// 名前：パレットの名前は描画時に置き換える
+	color, err := ParseColor(name)
+	if err != nil {
+		color = Color{unresolved: true}
+	}
+	color.Name = name
+	*C = color
+	return nil
+}
+
+// 不透明度（0〜1、省略時は不透明）
+func (C Color) Alpha() float64 {
+	if C.A == nil {
+		return 1
+	}
+	return *C.A
+}
+
+// CMYK に変換（CMYK で指定した色はそのまま）
+func (C Color) ToCMYK() CMYK {
+	if C.CMYK != nil {
+		return *C.CMYK
+	}
+	r, g, b := float64(C.R)/255, float64(C.G)/255, float64(C.B)/255
+	k := 1 - math.Max(r, math.Max(g, b))
+	if k >= 1 {
+		return CMYK{K: 100}
+	}
+	percent := func(value float64) uint8 {
+		return uint8(math.Round((1 - value - k) / (1 - k) * 100))
+	}
+	return CMYK{C: percent(r), M: percent(g), Y: percent(b), K: uint8(math.Round(k * 100))}
+}
+
+// 解析：色の文字列表記
+func ParseColor(text string) (Color, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if strings.HasPrefix(text, "#") {
+		return parseHexColor(text)
+	}
+	if strings.HasPrefix(text, "rgb(") || strings.HasPrefix(text, "rgba(") {
+		return parseRGBColor(text)
+	}
+	if strings.HasPrefix(text, "cmyk(") || strings.HasPrefix(text, "device-cmyk(") {
+		return parseCMYKColor(text)
+	}
+	if color, ok := namedColors[text]; ok {
+		if color.A != nil {
+			alpha := *color.A
+			color.A = &alpha
+		}
+		return color, nil
+	}
+	return Color{}, fmt.Errorf("invalid color %q", text)
+}
+
+// 解析："#RGB" / "#RGBA" / "#RRGGBB" / "#RRGGBBAA"
+func parseHexColor(text string) (Color, error) {
+	hex := strings.TrimPrefix(text, "#")
+	if len(hex) == 3 || len(hex) == 4 {
+		var expanded string
+		for _, c := range hex {
+			expanded += string(c) + string(c)
+		}
+		hex = expanded
+	}
+	if len(hex) != 6 && len(hex) != 8 {
+		return Color{}, fmt.Errorf("invalid color %q", text)
+	}
+
+	var values []uint8
+	for i := 0; i < len(hex); i += 2 {
+		value, err := strconv.ParseUint(hex[i:i+2], 16, 8)
+		if err != nil {
+			return Color{}, fmt.Errorf("invalid color %q", text)
+		}
+		values = append(values, uint8(value))
+	}
+	color := Color{R: values[0], G: values[1], B: values[2]}
+	if len(values) == 4 {
+		alpha := float64(values[3]) / 255
+		color.A = &alpha
+	}
+	return color, nil
+}
+
+// 解析："rgb(255, 0, 0)" / "rgba(255, 0, 0, 0.5)"（値は 0〜255 または %）
+func parseRGBColor(text string) (Color, error) {
+	open := strings.Index(text, "(")
+	if !strings.HasSuffix(text, ")") {
+		return Color{}, fmt.Errorf("invalid color %q", text)
+	}
+	fields := strings.FieldsFunc(text[open+1:len(text)-1], func(r rune) bool {
+		return r == ',' || r == ' ' || r == '/'
+	})
+	if len(fields) != 3 && len(fields) != 4 {
+		return Color{}, fmt.Errorf("invalid color %q", text)
+	}
+
+	var values []float64
+	for i, field := range fields {
+		percent := strings.HasSuffix(field, "%")
+		value, err := strconv.ParseFloat(strings.TrimSuffix(field, "%"), 64)
+		if err != nil {
+			return Color{}, fmt.Errorf("invalid color %q", text)
+		}
+		if i < 3 {
+			if percent {
+				value = value * 255 / 100
+			}
+			value = math.Round(math.Min(math.Max(value, 0), 255))
+		} else {
+			if percent {
+				value = value / 100
+			}
+			value = math.Min(math.Max(value, 0), 1)
+		}
+		values = append(values, value)
+	}
+	color := Color{R: uint8(values[0]), G: uint8(values[1]), B: uint8(values[2])}
+	if len(values) == 4 {
+		color.A = &values[3]
+	}
+	return color, nil
+}
+
+// 解析："cmyk(0, 100, 100, 0)" / "device-cmyk(0%, 100%, 100%, 0%, 0.5)"（値は 0〜100 または %、5 番目は不透明度）
+func parseCMYKColor(text string) (Color, error) {
+	open := strings.Index(text, "(")
+	if !strings.HasSuffix(text, ")") {
+		return Color{}, fmt.Errorf("invalid color %q", text)
+	}
+	fields := strings.FieldsFunc(text[open+1:len(text)-1], func(r rune) bool {
+		return r == ',' || r == ' ' || r == '/'
+	})
+	if len(fields) != 4 && len(fields) != 5 {
+		return Color{}, fmt.Errorf("invalid color %q", text)
+	}
+
+	var values []float64
+	for i, field := range fields {
+		percent := strings.HasSuffix(field, "%")
+		value, err := strconv.ParseFloat(strings.TrimSuffix(field, "%"), 64)
+		if err != nil {
+			return Color{}, fmt.Errorf("invalid color %q", text)
+		}
+		if i < 4 {
+			value = math.Round(math.Min(math.Max(value, 0), 100))
+		} else {
+			if percent {
+				value = value / 100
+			}
+			value = math.Min(math.Max(value, 0), 1)
+		}
+		values = append(values, value)
+	}
+	cmyk := CMYK{C: uint8(values[0]), M: uint8(values[1]), Y: uint8(values[2]), K: uint8(values[3])}
+	color := Color{CMYK: &cmyk}
+	if len(values) == 5 {
+		color.A = &values[4]
+	}
+	return color, nil
 }
@@ -0,0 +1,13 @@
+package types
+
+const ColorSpaceRGB = "rgb"
+const ColorSpaceCMYK = "cmyk"
+
+type ColorSpace string
+
+func (C ColorSpace) IsRGB() bool {
+	return C == ColorSpaceRGB || C == ""
+}
+func (C ColorSpace) IsCMYK() bool {
+	return C == ColorSpaceCMYK
+}
@@ -0,0 +1,169 @@
+package types
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		text  string
+		want  Color
+		alpha float64
+	}{
+		{text: "#f00", want: Color{R: 255}, alpha: 1},
+		{text: "#00FF0080", want: Color{G: 255}, alpha: 128.0 / 255},
+		{text: "#1a2b3c", want: Color{R: 0x1a, G: 0x2b, B: 0x3c}, alpha: 1},
+		{text: "rgb(255, 128, 0)", want: Color{R: 255, G: 128}, alpha: 1},
+		{text: "rgb(100% 50% 0%)", want: Color{R: 255, G: 128}, alpha: 1},
+		{text: "rgba(0, 0, 255, 0.5)", want: Color{B: 255}, alpha: 0.5},
+		{text: "rgba(0, 0, 255, 25%)", want: Color{B: 255}, alpha: 0.25},
+		{text: "rgb(300, -1, 0)", want: Color{R: 255}, alpha: 1},
+		{text: "cmyk(0, 100, 100, 0)", want: Color{CMYK: &CMYK{M: 100, Y: 100}}, alpha: 1},
+		{text: "device-cmyk(10%, 20%, 30%, 40%, 0.5)", want: Color{CMYK: &CMYK{C: 10, M: 20, Y: 30, K: 40}}, alpha: 0.5},
+		{text: " Red ", want: Color{R: 255}, alpha: 1},
+		{text: "rebeccapurple", want: Color{R: 102, G: 51, B: 153}, alpha: 1},
+		{text: "transparent", want: Color{}, alpha: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			got, err := ParseColor(test.text)
+			if err != nil {
+				t.Fatalf("ParseColor(%q) error: %v", test.text, err)
+			}
+			if got.R != test.want.R || got.G != test.want.G || got.B != test.want.B || !reflect.DeepEqual(got.CMYK, test.want.CMYK) {
+				t.Errorf("ParseColor(%q) = %+v, want %+v", test.text, got, test.want)
+			}
+			if math.Abs(got.Alpha()-test.alpha) > 1e-9 {
+				t.Errorf("ParseColor(%q).Alpha() = %v, want %v", test.text, got.Alpha(), test.alpha)
+			}
+		})
+	}
+}
+
+func TestParseColorInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"#12",
+		"#12345",
+		"#gggggg",
+		"rgb(1, 2)",
+		"rgb(1, 2, 3",
+		"rgba(a, b, c, d)",
+		"cmyk(1, 2, 3)",
+		"device-cmyk(1, 2, 3, 4, 5, 6)",
+		"nocolor",
+	}
+	for _, text := range tests {
+		t.Run(text, func(t *testing.T) {
+			if got, err := ParseColor(text); err == nil {
+				t.Errorf("ParseColor(%q) = %+v, want error", text, got)
+			}
+		})
+	}
+}
+
+func TestColorUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		json  string
+		want  Color
+		alpha float64
+	}{
+		{json: `{"r": 1, "g": 2, "b": 3}`, want: Color{R: 1, G: 2, B: 3}, alpha: 1},
+		{json: `{"r": 1, "g": 2, "b": 3, "a": 0.5}`, want: Color{R: 1, G: 2, B: 3}, alpha: 0.5},
+		{json: `{"cmyk": {"c": 100, "k": 20}}`, want: Color{CMYK: &CMYK{C: 100, K: 20}}, alpha: 1},
+		{json: `"#0000ff"`, want: Color{B: 255}, alpha: 1},
+		{json: `"rgba(255, 0, 0, 0.25)"`, want: Color{R: 255}, alpha: 0.25},
+		{json: `"red"`, want: Color{R: 255, Name: "red"}, alpha: 1},
+		{json: `"primary"`, want: Color{Name: "primary", unresolved: true}, alpha: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.json, func(t *testing.T) {
+			var got Color
+			if err := json.Unmarshal([]byte(test.json), &got); err != nil {
+				t.Fatalf("Unmarshal(%s) error: %v", test.json, err)
+			}
+			alpha := got.Alpha()
+			got.A = nil
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Unmarshal(%s) = %+v, want %+v", test.json, got, test.want)
+			}
+			if math.Abs(alpha-test.alpha) > 1e-9 {
+				t.Errorf("Unmarshal(%s).Alpha() = %v, want %v", test.json, alpha, test.alpha)
+			}
+		})
+	}
+
+	for _, text := range []string{`"#12"`, `"rgb(1, 2)"`, `"cmyk(1, 2, 3)"`} {
+		t.Run(text, func(t *testing.T) {
+			var got Color
+			if err := json.Unmarshal([]byte(text), &got); err == nil {
+				t.Errorf("Unmarshal(%s) = %+v, want error", text, got)
+			}
+		})
+	}
+}
+
+func TestColorToCMYK(t *testing.T) {
+	tests := []struct {
+		name  string
+		color Color
+		want  CMYK
+	}{
+		{name: "white", color: Color{R: 255, G: 255, B: 255}, want: CMYK{}},
+		{name: "black", color: Color{}, want: CMYK{K: 100}},
+		{name: "red", color: Color{R: 255}, want: CMYK{M: 100, Y: 100}},
+		{name: "dark orange", color: Color{R: 128, G: 64}, want: CMYK{M: 50, Y: 100, K: 50}},
+		{name: "gray", color: Color{R: 51, G: 51, B: 51}, want: CMYK{K: 80}},
+		{name: "cmyk as is", color: Color{R: 255, CMYK: &CMYK{C: 10, M: 20, Y: 30, K: 40}}, want: CMYK{C: 10, M: 20, Y: 30, K: 40}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.color.ToCMYK(); got != test.want {
+				t.Errorf("ToCMYK() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestElementTextResolvePalette(t *testing.T) {
+	palette := map[string]Color{}
+	if err := json.Unmarshal([]byte(`{"primary": "#ff0000", "broken": "unknown-name"}`), &palette); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		json    string
+		want    Color
+		wantErr string
+	}{
+		{name: "palette name", json: `{"color": "primary"}`, want: Color{R: 255}},
+		{name: "css name", json: `{"color": "blue"}`, want: Color{B: 255, Name: "blue"}},
+		{name: "span color", json: `{"spans": [{"text": "a"}, {"text": "b", "color": "nothing"}]}`, wantErr: `spans[1].color: invalid color "nothing"`},
+		{name: "unresolved palette color", json: `{"background_color": "broken"}`, wantErr: `background_color: invalid color "unknown-name" in palette "broken"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var decoded ElementText
+			if err := json.Unmarshal([]byte(test.json), &decoded); err != nil {
+				t.Fatal(err)
+			}
+			err := decoded.ResolvePalette(palette)
+			if test.wantErr != "" {
+				if err == nil || err.Error() != test.wantErr {
+					t.Fatalf("ResolvePalette() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded.Color, test.want) {
+				t.Errorf("ResolvePalette() color = %+v, want %+v", decoded.Color, test.want)
+			}
+		})
+	}
+}
@@ -1,20 +1,26 @@
 package types
 
 type DocumentConfigure struct {
-	Width         float64           `json:"width"`
-	Height        float64           `json:"height"`
-	Margin        Margin            `json:"margin"`
-	TextSize      int               `json:"text_size"`
-	TextColor     Color             `json:"text_color"`
-	CommonHeader  Header            `json:"header"`
-	CommonFooter  Footer            `json:"footer"`
-	Pages         []Page            `json:"pages"`
-	AutoPageBreak bool              `json:"auto_page_break,string"`
-	CompressLevel int               `json:"compress_level"`
-	Password      string            `json:"password"`
-	TTFPath       string            `json:"-"`
-	Templates     []ElementTemplate `json:"templates"`
-	fontHeight    float64           `json:"-"`
+	Width         float64               `json:"width"`
+	Height        float64               `json:"height"`
+	Margin        Margin                `json:"margin"`
+	TextSize      int                   `json:"text_size"`
+	TextColor     Color                 `json:"text_color"`
+	CommonHeader  Header                `json:"header"`
+	CommonFooter  Footer                `json:"footer"`
+	Pages         []Page                `json:"pages"`
+	AutoPageBreak bool                  `json:"auto_page_break,string"`
+	CompressLevel int                   `json:"compress_level"`
+	Password      string                `json:"password"`
+	TTFPath       string                `json:"-"`
+	Data          interface{}           `json:"-"`
+	Templates     []ElementTemplate     `json:"templates"`
+	Fonts         map[string]FontFamily `json:"fonts"`
+	FallbackFonts []string              `json:"fallback_fonts"`
+	Watermark     Watermark             `json:"watermark"`
+	Palette       map[string]Color      `json:"palette"`
+	ColorSpace    ColorSpace            `json:"color_space"`
+	fontHeight    float64               `json:"-"`
 }
 
 func (D *DocumentConfigure) FontHeight() float64 {
@@ -23,3 +29,11 @@ func (D *DocumentConfigure) FontHeight() float64 {
 func (D *DocumentConfigure) SetFontHeight(textHeight float64) {
 	D.fontHeight = textHeight
 }
+
+// パレット：文書の設定の名前で指定した色をパレットの色に置き換える（要素の色は描画時に属性を読み込むときに置き換える）
+func (D *DocumentConfigure) ResolvePalette() error {
+	return resolvePalette(D.Palette, []paletteColor{
+		{"text_color", &D.TextColor},
+		{"watermark.color", &D.Watermark.Color},
+	})
+}
@@ -1,10 +1,14 @@
 package types
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 type Element struct {
 	Type       ElementType     `json:"type"`
 	TemplateId string          `json:"template_id"`
+	VisibleIf  string          `json:"visible_if"`
 	Attributes json.RawMessage `json:"attributes"`
 }
 
@@ -13,37 +17,94 @@ type ElementLineBreak struct {
 }
 
 type ElementText struct {
-	Text            string        `json:"text"`
-	TextSize        int           `json:"text_size"`
-	Color           Color         `json:"color"`
-	Size            Size          `json:"size"`
-	Origin          Origin        `json:"origin"`
-	Border          Border        `json:"border"`
-	BorderTop       Border        `json:"border_top"`
-	BorderRight     Border        `json:"border_right"`
-	BorderBottom    Border        `json:"border_bottom"`
-	BorderLeft      Border        `json:"border_left"`
-	BackgroundColor Color         `json:"background_color"`
-	Align           Align         `json:"align"`
-	Valign          Valign        `json:"valign"`
-	Wrap            bool          `json:"wrap,string"`
-	Margin          Margin        `json:"margin"`
-	ContentMargin   ContentMargin `json:"content_margin"`
-	Layout          Layout        `json:"layout"`
+	Text             string         `json:"text"`
+	Spans            []TextSpan     `json:"spans"`
+	TextSize         int            `json:"text_size"`
+	FontFamily       string         `json:"font_family"`
+	FontStyle        FontStyle      `json:"font_style"`
+	Color            Color          `json:"color"`
+	Size             Size           `json:"size"`
+	Origin           Origin         `json:"origin"`
+	Border           Border         `json:"border"`
+	BorderTop        Border         `json:"border_top"`
+	BorderRight      Border         `json:"border_right"`
+	BorderBottom     Border         `json:"border_bottom"`
+	BorderLeft       Border         `json:"border_left"`
+	BackgroundColor  *Color         `json:"background_color"`
+	BorderRadius     BorderRadius   `json:"border_radius"`
+	Decoration       TextDecoration `json:"decoration"`
+	Align            Align          `json:"align"`
+	Valign           Valign         `json:"valign"`
+	Wrap             bool           `json:"wrap,string"`
+	WritingMode      WritingMode    `json:"writing_mode"`
+	LineHeight       LineHeight     `json:"line_height"`
+	LetterSpacing    float64        `json:"letter_spacing"`
+	ParagraphSpacing float64        `json:"paragraph_spacing"`
+	Fit              Fit            `json:"fit"`
+	MinTextSize      int            `json:"min_text_size"`
+	Rotation         float64        `json:"rotation"`
+	RotationAnchor   Anchor         `json:"rotation_anchor"`
+	Margin           Margin         `json:"margin"`
+	ContentMargin    ContentMargin  `json:"content_margin"`
+	Layout           Layout         `json:"layout"`
 }
 
 type ElementImage struct {
-	Path          string        `json:"path"`
-	Size          Size          `json:"size"`
-	Origin        Origin        `json:"origin"`
-	Resize        bool          `json:"resize,string"`
-	Resolution    uint          `json:"resolution"`
-	Margin        Margin        `json:"margin"`
-	ContentMargin ContentMargin `json:"content_margin"`
-	Border        Border        `json:"border"`
-	BorderTop     Border        `json:"border_top"`
-	BorderRight   Border        `json:"border_right"`
-	BorderBottom  Border        `json:"border_bottom"`
-	BorderLeft    Border        `json:"border_left"`
-	Layout        Layout        `json:"layout"`
+	Path           string        `json:"path"`
+	Size           Size          `json:"size"`
+	Origin         Origin        `json:"origin"`
+	Resize         bool          `json:"resize,string"`
+	Resolution     uint          `json:"resolution"`
+	Margin         Margin        `json:"margin"`
+	ContentMargin  ContentMargin `json:"content_margin"`
+	Border         Border        `json:"border"`
+	BorderTop      Border        `json:"border_top"`
+	BorderRight    Border        `json:"border_right"`
+	BorderBottom   Border        `json:"border_bottom"`
+	BorderLeft     Border        `json:"border_left"`
+	BorderRadius   BorderRadius  `json:"border_radius"`
+	Rotation       float64       `json:"rotation"`
+	RotationAnchor Anchor        `json:"rotation_anchor"`
+	Opacity        *float64      `json:"opacity"`
+	Layout         Layout        `json:"layout"`
+}
+
+// パレット：名前で指定した色をパレットの色に置き換える
+func (E *ElementText) ResolvePalette(palette map[string]Color) error {
+	colors := []paletteColor{
+		{"color", &E.Color},
+		{"background_color", E.BackgroundColor},
+		{"border.color", &E.Border.Color},
+		{"border_top.color", &E.BorderTop.Color},
+		{"border_right.color", &E.BorderRight.Color},
+		{"border_bottom.color", &E.BorderBottom.Color},
+		{"border_left.color", &E.BorderLeft.Color},
+		{"decoration.color", E.Decoration.Color},
+	}
+	for i := range E.Spans {
+		colors = append(colors,
+			paletteColor{fmt.Sprintf("spans[%d].color", i), E.Spans[i].Color},
+			paletteColor{fmt.Sprintf("spans[%d].background_color", i), E.Spans[i].BackgroundColor},
+		)
+	}
+	return resolvePalette(palette, colors)
+}
+
+// 不透明度（省略時は 1）
+func (E ElementImage) Alpha() float64 {
+	if E.Opacity == nil {
+		return 1
+	}
+	return *E.Opacity
+}
+
+// パレット：名前で指定した色をパレットの色に置き換える
+func (E *ElementImage) ResolvePalette(palette map[string]Color) error {
+	return resolvePalette(palette, []paletteColor{
+		{"border.color", &E.Border.Color},
+		{"border_top.color", &E.BorderTop.Color},
+		{"border_right.color", &E.BorderRight.Color},
+		{"border_bottom.color", &E.BorderBottom.Color},
+		{"border_left.color", &E.BorderLeft.Color},
+	})
 }
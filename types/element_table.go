@@ -0,0 +1,74 @@
+package types
+
+import "fmt"
+
+type ElementTable struct {
+	Columns         []TableColumn `json:"columns"`
+	Rows            []TableRow    `json:"rows"`
+	HeaderRows      int           `json:"header_rows"`
+	TextSize        int           `json:"text_size"`
+	FontFamily      string        `json:"font_family"`
+	FontStyle       FontStyle     `json:"font_style"`
+	Color           Color         `json:"color"`
+	BackgroundColor *Color        `json:"background_color"`
+	Border          Border        `json:"border"`
+	CellPadding     Margin        `json:"cell_padding"`
+	Size            Size          `json:"size"`
+	Origin          Origin        `json:"origin"`
+	Margin          Margin        `json:"margin"`
+	Layout          Layout        `json:"layout"`
+}
+
+type TableColumn struct {
+	Type  TableColumnType `json:"type"`
+	Width float64         `json:"width"`
+	Ratio float64         `json:"ratio"`
+}
+
+type TableRow struct {
+	Height float64     `json:"height"`
+	Cells  []TableCell `json:"cells"`
+}
+
+type TableCell struct {
+	Text            string    `json:"text"`
+	ColSpan         int       `json:"col_span"`
+	RowSpan         int       `json:"row_span"`
+	TextSize        int       `json:"text_size"`
+	FontFamily      string    `json:"font_family"`
+	FontStyle       FontStyle `json:"font_style"`
+	Color           *Color    `json:"color"`
+	BackgroundColor *Color    `json:"background_color"`
+	Border          Border    `json:"border"`
+	BorderTop       Border    `json:"border_top"`
+	BorderRight     Border    `json:"border_right"`
+	BorderBottom    Border    `json:"border_bottom"`
+	BorderLeft      Border    `json:"border_left"`
+	Align           Align     `json:"align"`
+	Valign          Valign    `json:"valign"`
+}
+
+// パレット：名前で指定した色をパレットの色に置き換える
+func (E *ElementTable) ResolvePalette(palette map[string]Color) error {
+	colors := []paletteColor{
+		{"color", &E.Color},
+		{"background_color", E.BackgroundColor},
+		{"border.color", &E.Border.Color},
+	}
+	for r := range E.Rows {
+		for c := range E.Rows[r].Cells {
+			cell := &E.Rows[r].Cells[c]
+			path := fmt.Sprintf("rows[%d].cells[%d].", r, c)
+			colors = append(colors,
+				paletteColor{path + "color", cell.Color},
+				paletteColor{path + "background_color", cell.BackgroundColor},
+				paletteColor{path + "border.color", &cell.Border.Color},
+				paletteColor{path + "border_top.color", &cell.BorderTop.Color},
+				paletteColor{path + "border_right.color", &cell.BorderRight.Color},
+				paletteColor{path + "border_bottom.color", &cell.BorderBottom.Color},
+				paletteColor{path + "border_left.color", &cell.BorderLeft.Color},
+			)
+		}
+	}
+	return resolvePalette(palette, colors)
+}
@@ -11,3 +11,6 @@ func (E ElementType) IsText() bool {
 func (E ElementType) IsImage() bool {
 	return E == "image"
 }
+func (E ElementType) IsTable() bool {
+	return E == "table"
+}
@@ -0,0 +1,13 @@
+package types
+
+const FitShrink = "shrink"
+const FitEllipsis = "ellipsis"
+
+type Fit string
+
+func (F Fit) IsShrink() bool {
+	return F == FitShrink
+}
+func (F Fit) IsEllipsis() bool {
+	return F == FitEllipsis
+}
@@ -0,0 +1,19 @@
+package types
+
+type FontFamily struct {
+	Regular    string `json:"regular"`
+	Bold       string `json:"bold"`
+	Italic     string `json:"italic"`
+	BoldItalic string `json:"bold_italic"`
+}
+
+func (F *FontFamily) Path(style FontStyle) string {
+	if style.IsBoldItalic() {
+		return F.BoldItalic
+	} else if style.IsBold() {
+		return F.Bold
+	} else if style.IsItalic() {
+		return F.Italic
+	}
+	return F.Regular
+}
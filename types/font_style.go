@@ -0,0 +1,33 @@
+package types
+
+const FontStyleRegular = "regular"
+const FontStyleBold = "bold"
+const FontStyleItalic = "italic"
+const FontStyleBoldItalic = "bold_italic"
+
+type FontStyle string
+
+func (F FontStyle) IsRegular() bool {
+	return F == FontStyleRegular || F == ""
+}
+func (F FontStyle) IsBold() bool {
+	return F == FontStyleBold
+}
+func (F FontStyle) IsItalic() bool {
+	return F == FontStyleItalic
+}
+func (F FontStyle) IsBoldItalic() bool {
+	return F == FontStyleBoldItalic
+}
+
+// 指定のスタイルがない場合に使うスタイル（優先順）
+func (F FontStyle) Fallbacks() []FontStyle {
+	if F.IsBoldItalic() {
+		return []FontStyle{FontStyleBoldItalic, FontStyleBold, FontStyleItalic, FontStyleRegular}
+	} else if F.IsBold() {
+		return []FontStyle{FontStyleBold, FontStyleRegular}
+	} else if F.IsItalic() {
+		return []FontStyle{FontStyleItalic, FontStyleRegular}
+	}
+	return []FontStyle{FontStyleRegular}
+}
@@ -0,0 +1,17 @@
+package types
+
+const LineCapButt = "butt"
+const LineCapRound = "round"
+const LineCapSquare = "square"
+
+type LineCap string
+
+func (L LineCap) IsButt() bool {
+	return L == LineCapButt || L == ""
+}
+func (L LineCap) IsRound() bool {
+	return L == LineCapRound
+}
+func (L LineCap) IsSquare() bool {
+	return L == LineCapSquare
+}
@@ -0,0 +1,50 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 行の高さ：数値は文字の高さに対する倍率、"pt" を付けた文字列は絶対値（例：1.5, "18pt"）
+type LineHeight struct {
+	Value    float64
+	Absolute bool
+}
+
+func (L *LineHeight) UnmarshalJSON(data []byte) error {
+	var number float64
+	if err := json.Unmarshal(data, &number); err == nil {
+		*L = LineHeight{Value: number}
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	text = strings.TrimSpace(text)
+	absolute := strings.HasSuffix(text, "pt")
+	value, err := strconv.ParseFloat(strings.TrimSuffix(text, "pt"), 64)
+	if err != nil {
+		return fmt.Errorf("invalid line_height %q", text)
+	}
+	*L = LineHeight{Value: value, Absolute: absolute}
+	return nil
+}
+
+func (L LineHeight) IsZero() bool {
+	return L.Value == 0
+}
+
+// 適用：行の高さ（未指定の場合はそのまま）
+func (L LineHeight) Apply(height float64) float64 {
+	if L.IsZero() {
+		return height
+	}
+	if L.Absolute {
+		return L.Value
+	}
+	return height * L.Value
+}
@@ -0,0 +1,17 @@
+package types
+
+const LineJoinMiter = "miter"
+const LineJoinRound = "round"
+const LineJoinBevel = "bevel"
+
+type LineJoin string
+
+func (L LineJoin) IsMiter() bool {
+	return L == LineJoinMiter || L == ""
+}
+func (L LineJoin) IsRound() bool {
+	return L == LineJoinRound
+}
+func (L LineJoin) IsBevel() bool {
+	return L == LineJoinBevel
+}
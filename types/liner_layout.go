@@ -6,4 +6,6 @@ type LinerLayout struct {
 	LinerLayouts []LinerLayout `json:"liner_layouts"`
 	Elements     []Element     `json:"elements"`
 	Layout       Layout        `json:"layout"`
+	Repeat       string        `json:"repeat"`
+	VisibleIf    string        `json:"visible_if"`
 }
@@ -0,0 +1,41 @@
+package types
+
+import "fmt"
+
+// パレットで置き換える色と、エラーに付ける属性の名前
+type paletteColor struct {
+	name  string
+	color *Color
+}
+
+// パレット：名前で指定した色をパレットの色に置き換える（パレットにない名前は CSS の色名として扱う）
+func (C *Color) ResolvePalette(palette map[string]Color) error {
+	if C.Name == "" {
+		return nil
+	}
+	if color, ok := palette[C.Name]; ok {
+		if color.unresolved {
+			return fmt.Errorf("invalid color %q in palette %q", color.Name, C.Name)
+		}
+		color.Name = ""
+		*C = color
+		return nil
+	}
+	if C.unresolved {
+		return fmt.Errorf("invalid color %q", C.Name)
+	}
+	return nil
+}
+
+// パレット：属性の色をまとめて置き換える（指定のない色は飛ばす）
+func resolvePalette(palette map[string]Color, colors []paletteColor) error {
+	for _, c := range colors {
+		if c.color == nil {
+			continue
+		}
+		if err := c.color.ResolvePalette(palette); err != nil {
+			return fmt.Errorf("%s: %w", c.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package types
+
+const TableColumnFixed = "fixed"
+const TableColumnRatio = "ratio"
+const TableColumnAuto = "auto"
+
+type TableColumnType string
+
+func (T TableColumnType) IsFixed() bool {
+	return T == TableColumnFixed
+}
+func (T TableColumnType) IsRatio() bool {
+	return T == TableColumnRatio
+}
+func (T TableColumnType) IsAuto() bool {
+	return T == TableColumnAuto || T == ""
+}
@@ -0,0 +1,12 @@
+package types
+
+type TextDecoration struct {
+	Underline     Underline `json:"underline"`
+	Strikethrough bool      `json:"strikethrough,string"`
+	Color         *Color    `json:"color"`
+	Thickness     float64   `json:"thickness"`
+}
+
+func (T TextDecoration) IsZero() bool {
+	return !T.Underline.IsSingle() && !T.Underline.IsDouble() && !T.Strikethrough
+}
@@ -0,0 +1,11 @@
+package types
+
+type TextSpan struct {
+	Text            string    `json:"text"`
+	TextSize        int       `json:"text_size"`
+	FontFamily      string    `json:"font_family"`
+	FontStyle       FontStyle `json:"font_style"`
+	Color           *Color    `json:"color"`
+	BackgroundColor *Color    `json:"background_color"`
+	Underline       bool      `json:"underline,string"`
+}
@@ -0,0 +1,13 @@
+package types
+
+const UnderlineSingle = "single"
+const UnderlineDouble = "double"
+
+type Underline string
+
+func (U Underline) IsSingle() bool {
+	return U == UnderlineSingle
+}
+func (U Underline) IsDouble() bool {
+	return U == UnderlineDouble
+}
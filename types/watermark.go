@@ -0,0 +1,28 @@
+package types
+
+type Watermark struct {
+	Text       string         `json:"text"`
+	TextSize   int            `json:"text_size"`
+	FontFamily string         `json:"font_family"`
+	FontStyle  FontStyle      `json:"font_style"`
+	Color      Color          `json:"color"`
+	Path       string         `json:"path"`
+	Size       Size           `json:"size"`
+	Rotation   float64        `json:"rotation"`
+	Opacity    *float64       `json:"opacity"`
+	Position   Anchor         `json:"position"`
+	Layer      WatermarkLayer `json:"layer"`
+	VisibleIf  string         `json:"visible_if"`
+}
+
+func (W *Watermark) IsZero() bool {
+	return W.Text == "" && W.Path == ""
+}
+
+// 不透明度（省略時は 1）
+func (W Watermark) Alpha() float64 {
+	if W.Opacity == nil {
+		return 1
+	}
+	return *W.Opacity
+}
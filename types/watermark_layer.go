@@ -0,0 +1,13 @@
+package types
+
+const WatermarkLayerBack = "back"
+const WatermarkLayerFront = "front"
+
+type WatermarkLayer string
+
+func (W WatermarkLayer) IsBack() bool {
+	return W == WatermarkLayerBack || W == ""
+}
+func (W WatermarkLayer) IsFront() bool {
+	return W == WatermarkLayerFront
+}
@@ -0,0 +1,13 @@
+package types
+
+const WritingModeHorizontalTB = "horizontal-tb"
+const WritingModeVerticalRL = "vertical-rl"
+
+type WritingMode string
+
+func (W WritingMode) IsHorizontal() bool {
+	return W == WritingModeHorizontalTB || W == ""
+}
+func (W WritingMode) IsVertical() bool {
+	return W == WritingModeVerticalRL
+}